@@ -1,3 +1,10 @@
 module github.com/mbStavola/slydes
 
-go 1.15
+go 1.18
+
+require (
+	golang.org/x/image v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/text v0.16.0 // indirect
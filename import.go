@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/mbStavola/slydes/pkg/convert"
+)
+
+// slydes import -from markdown -file deck.md > deck.sly
+//
+// Converts a presentation written in another format into Sly
+func importCommand(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	from := flags.String("from", "markdown", "format to convert from (markdown)")
+	filename := flags.String("file", "", "presentation to convert")
+
+	flags.Parse(args)
+
+	if *filename == "" {
+		fmt.Print("Filename must be provided")
+		return
+	} else if *from != "markdown" {
+		fmt.Print("Only markdown can be imported")
+		return
+	}
+
+	source, err := ioutil.ReadFile(*filename)
+	if err != nil {
+		fmt.Print(err)
+		return
+	}
+
+	fmt.Print(convert.Markdown(string(source)))
+}
@@ -4,15 +4,28 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
 
 	"github.com/mbStavola/slydes/pkg/lang"
+	"github.com/mbStavola/slydes/pkg/schema"
+	"github.com/mbStavola/slydes/pkg/types"
 	"github.com/mbStavola/slydes/render/html"
+	"github.com/mbStavola/slydes/render/image"
+	"github.com/mbStavola/slydes/render/markdown"
 )
 
 func main() {
-	filename := flag.String("file", "", "slide to open")
-	output := flag.String("out", "noop", "method of display (noop, html)")
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		importCommand(os.Args[2:])
+		return
+	}
+
+	filename := flag.String("file", "", "slide to open (.sly, or a compiled show as .json or .yaml)")
+	output := flag.String("out", "noop", "method of display (noop, html, reveal, markdown, outline, png, svg, json, yaml)")
+	dir := flag.String("dir", ".", "directory to write into, for outputs with a file per slide (png, svg)")
+	scale := flag.Float64("scale", 1, "size of each image relative to the slide, for png output")
+	library := flag.String("reveal", "", "copy of reveal.js to inline into the page, for reveal output (linked from a CDN if not given)")
+	theme := flag.String("theme", "", "theme to use instead of the file's own (light, dark, high-contrast, or a .sly file)")
 	debug := flag.Bool("debug", false, "print debug info")
 
 	flag.Parse()
@@ -20,11 +33,14 @@ func main() {
 	if *filename == "" {
 		fmt.Print("Filename must be provided")
 		return
-	} else if !strings.HasSuffix(*filename, ".sly") {
-		fmt.Print("Only .sly files are supported")
+	} else if format := filepath.Ext(*filename); format != ".sly" && format != ".json" && format != ".yaml" && format != ".yml" {
+		fmt.Print("Only .sly, .json and .yaml files are supported")
 		return
-	} else if *output != "native" && *output != "html" && *output != "noop" {
-		fmt.Print("Output must be either noop or html")
+	} else if *theme != "" && format != ".sly" {
+		fmt.Print("Themes only apply to .sly files")
+		return
+	} else if *output != "native" && *output != "html" && *output != "reveal" && *output != "markdown" && *output != "outline" && *output != "png" && *output != "svg" && *output != "json" && *output != "yaml" && *output != "noop" {
+		fmt.Print("Output must be one of noop, html, reveal, markdown, outline, png, svg, json or yaml")
 		return
 	}
 
@@ -35,12 +51,35 @@ func main() {
 	}
 	defer file.Close()
 
-	sly := lang.NewSly()
-	if *debug {
-		sly = debugSly(sly)
+	var show types.Show
+	switch filepath.Ext(*filename) {
+	case ".json":
+		show, err = schema.ReadJSON(file)
+	case ".yaml", ".yml":
+		show, err = schema.ReadYAML(file)
+	default:
+		compiler := lang.NewDefaultCompiler()
+		compiler.Theme = *theme
+		compiler.BaseDir = filepath.Dir(*filename)
+
+		// Unlike a @theme directive, a theme file given here is
+		// relative to where slydes is run rather than to the file
+		if *theme != "" && !lang.IsBuiltinTheme(*theme) {
+			if compiler.Theme, err = filepath.Abs(*theme); err != nil {
+				fmt.Print(err)
+				return
+			}
+		}
+
+		sly := lang.NewSly()
+		sly.Compiler = compiler
+		if *debug {
+			sly = debugSly(sly)
+		}
+
+		show, err = sly.ReadSlideShow(file)
 	}
 
-	show, err := sly.ReadSlideShow(file)
 	if err != nil {
 		fmt.Print(err)
 		return
@@ -52,5 +91,33 @@ func main() {
 		if err := html.Render(show); err != nil {
 			fmt.Print(err)
 		}
+	case "reveal":
+		if err := html.RenderReveal(show, *library); err != nil {
+			fmt.Print(err)
+		}
+	case "markdown":
+		if err := markdown.Render(show); err != nil {
+			fmt.Print(err)
+		}
+	case "outline":
+		if err := markdown.RenderOutline(show); err != nil {
+			fmt.Print(err)
+		}
+	case "png":
+		if err := image.Render(show, *dir, *scale); err != nil {
+			fmt.Print(err)
+		}
+	case "svg":
+		if err := image.RenderSVG(show, *dir); err != nil {
+			fmt.Print(err)
+		}
+	case "json":
+		if err := schema.WriteJSON(os.Stdout, show); err != nil {
+			fmt.Print(err)
+		}
+	case "yaml":
+		if err := schema.WriteYAML(os.Stdout, show); err != nil {
+			fmt.Print(err)
+		}
 	}
 }
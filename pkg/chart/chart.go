@@ -0,0 +1,316 @@
+// Package chart lays out the charts in chart blocks as simple
+// shapes, so that they can be drawn by any renderer
+package chart
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/mbStavola/slydes/pkg/css"
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+type Point struct {
+	X float64
+	Y float64
+}
+
+// A Mark is one of the shapes a chart is drawn with: a Rect,
+// Polyline, Circle, Wedge or Label
+type Mark interface {
+	isMark()
+}
+
+type Rect struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+	Fill   color.Color
+}
+
+type Polyline struct {
+	Points []Point
+	Stroke color.Color
+	Width  float64
+}
+
+type Circle struct {
+	Center Point
+	Radius float64
+	Fill   color.Color
+}
+
+// A Wedge is a slice of a pie. Angles are in radians, measured
+// clockwise from the right, and a full turn draws a whole circle
+type Wedge struct {
+	Center Point
+	Radius float64
+	Start  float64
+	Sweep  float64
+	Fill   color.Color
+}
+
+type Anchor int
+
+const (
+	Start Anchor = iota
+	Middle
+	End
+)
+
+type Baseline int
+
+const (
+	Top Baseline = iota
+	Central
+	Bottom
+)
+
+// A Label is a single line of text drawn in the chart's ink, where the
+// anchor and baseline say which part of the text sits at its position
+type Label struct {
+	Text     string
+	At       Point
+	Anchor   Anchor
+	Baseline Baseline
+	// Turned a quarter turn counterclockwise, to read from bottom to top
+	Vertical bool
+}
+
+func (Rect) isMark()     {}
+func (Polyline) isMark() {}
+func (Circle) isMark()   {}
+func (Wedge) isMark()    {}
+func (Label) isMark()    {}
+
+// A Layout is a chart arranged into marks, measured in pixels with
+// (0, 0) being the top left corner. Marks are listed in the order
+// they are drawn, so later marks sit on top of earlier ones
+type Layout struct {
+	Width  float64
+	Height float64
+	// The color of the chart's text and axes
+	Ink   color.Color
+	Marks []Mark
+}
+
+func (l *Layout) add(marks ...Mark) {
+	l.Marks = append(l.Marks, marks...)
+}
+
+// Arrange lays out a chart to fill the given width and height, with
+// text of the given size and its text and axes in the given color
+func Arrange(chart types.Chart, width float64, height float64, fontSize float64, ink color.Color) Layout {
+	layout := Layout{Width: width, Height: height, Ink: ink}
+
+	if chart.Kind == types.PieChart {
+		arrangePie(&layout, chart, fontSize)
+	} else {
+		arrangeAxes(&layout, chart, fontSize)
+	}
+
+	return layout
+}
+
+// SVG draws a chart filling the given width and height, with its text and
+// axes in the given color. The id must be unique within the document
+func SVG(chart types.Chart, id string, width float64, height float64, fontSize float64, ink color.Color) string {
+	return Arrange(chart, width, height, fontSize, ink).SVG(id, fontSize)
+}
+
+// Bar and line charts share their axes, gridlines and legend
+func arrangeAxes(layout *Layout, chart types.Chart, fontSize float64) {
+	low, high := 0.0, 0.0
+	for _, series := range chart.Series {
+		for _, value := range series.Values {
+			low, high = math.Min(low, value), math.Max(high, value)
+		}
+	}
+
+	ticks := niceTicks(low, high)
+	low, high = ticks[0], ticks[len(ticks)-1]
+
+	// Work inwards from each edge to find the area left for plotting
+	gap := fontSize / 2
+	left, right, top, bottom := gap, layout.Width-gap, gap, layout.Height-gap
+	if chart.YLabel != "" {
+		left += fontSize * 1.5
+	}
+	if chart.XLabel != "" {
+		bottom -= fontSize * 1.5
+	}
+	if len(chart.Series) > 1 {
+		top += fontSize * 1.5
+	}
+
+	widest := 0.0
+	for _, tick := range ticks {
+		widest = math.Max(widest, css.TextWidth(formatTick(tick, ticks), fontSize))
+	}
+
+	left += widest + gap
+	bottom -= fontSize * 1.5
+	top += fontSize / 2
+
+	y := func(value float64) float64 {
+		if high == low {
+			return bottom
+		}
+
+		// Halved, so that the span of the widest ranges doesn't overflow
+		return bottom - (value/2-low/2)/(high/2-low/2)*(bottom-top)
+	}
+
+	faint := withAlpha(layout.Ink, 0.2)
+	for _, tick := range ticks {
+		layout.add(
+			Polyline{Points: []Point{{left, y(tick)}, {right, y(tick)}}, Stroke: faint, Width: 1},
+			Label{Text: formatTick(tick, ticks), At: Point{left - gap, y(tick)}, Anchor: End, Baseline: Central},
+		)
+	}
+
+	// Each category gets an equal share of the width, with its label centered below
+	band := (right - left) / math.Max(1, float64(len(chart.Categories)))
+	for i, category := range chart.Categories {
+		layout.add(Label{Text: category, At: Point{left + band*(float64(i)+0.5), bottom + gap}, Anchor: Middle, Baseline: Top})
+	}
+
+	if chart.Kind == types.LineChart {
+		arrangeLines(layout, chart, left, band, y, fontSize)
+	} else {
+		arrangeBars(layout, chart, left, band, y)
+	}
+
+	// The axes are added last so that they sit on top of the bars
+	layout.add(Polyline{Points: []Point{{left, top}, {left, bottom}, {right, bottom}}, Stroke: layout.Ink, Width: 1.5})
+	if low < 0 {
+		layout.add(Polyline{Points: []Point{{left, y(0)}, {right, y(0)}}, Stroke: layout.Ink, Width: 1})
+	}
+
+	if chart.XLabel != "" {
+		layout.add(Label{Text: chart.XLabel, At: Point{(left + right) / 2, layout.Height - gap}, Anchor: Middle, Baseline: Bottom})
+	}
+	if chart.YLabel != "" {
+		layout.add(Label{Text: chart.YLabel, At: Point{gap, (top + bottom) / 2}, Anchor: Middle, Baseline: Top, Vertical: true})
+	}
+
+	if len(chart.Series) > 1 {
+		names := make([]string, len(chart.Series))
+		for i, series := range chart.Series {
+			names[i] = series.Name
+		}
+
+		arrangeLegend(layout, chart, names, left, gap, fontSize)
+	}
+}
+
+func arrangeBars(layout *Layout, chart types.Chart, left float64, band float64, y func(float64) float64) {
+	// Bars fill most of their category, leaving a gap between categories
+	barWidth := band * 0.8 / math.Max(1, float64(len(chart.Series)))
+	for i, series := range chart.Series {
+		for j, value := range series.Values {
+			from, to := y(0), y(value)
+			layout.add(Rect{
+				X:      left + band*(float64(j)+0.1) + barWidth*float64(i),
+				Y:      math.Min(from, to),
+				Width:  barWidth,
+				Height: math.Abs(from - to),
+				Fill:   chart.Color(i),
+			})
+		}
+	}
+}
+
+func arrangeLines(layout *Layout, chart types.Chart, left float64, band float64, y func(float64) float64, fontSize float64) {
+	for i, series := range chart.Series {
+		points := make([]Point, len(series.Values))
+		for j, value := range series.Values {
+			points[j] = Point{left + band*(float64(j)+0.5), y(value)}
+		}
+
+		layout.add(Polyline{Points: points, Stroke: chart.Color(i), Width: fontSize / 6})
+		for _, point := range points {
+			layout.add(Circle{Center: point, Radius: fontSize / 4, Fill: chart.Color(i)})
+		}
+	}
+}
+
+// Add a row of color swatches with their names, starting from the left
+func arrangeLegend(layout *Layout, chart types.Chart, names []string, left float64, top float64, fontSize float64) {
+	x := left
+	for i, name := range names {
+		layout.add(
+			Rect{X: x, Y: top + fontSize*0.1, Width: fontSize * 0.8, Height: fontSize * 0.8, Fill: chart.Color(i)},
+			Label{Text: name, At: Point{x + fontSize, top + fontSize/2}, Baseline: Central},
+		)
+
+		x += fontSize*2 + css.TextWidth(name, fontSize)
+	}
+}
+
+// Choose evenly spaced ticks covering the range, stepping by 1, 2 or 5
+// times a power of ten so that the labels are easy to read. There are
+// always at least two ticks, however unreasonable the range
+func niceTicks(low float64, high float64) []float64 {
+	if !isFinite(low) || !isFinite(high) {
+		low, high = 0, 1
+	}
+	if high == low {
+		high = low + 1
+	}
+
+	rough := (high - low) / 5
+	magnitude := math.Pow(10, math.Floor(math.Log10(rough)))
+
+	step := magnitude * 10
+	for _, multiple := range []float64{1, 2, 5} {
+		if multiple*magnitude >= rough {
+			step = multiple * magnitude
+			break
+		}
+	}
+
+	ticks := make([]float64, 0, 7)
+	if isFinite(step) && step > 0 {
+		for tick := math.Floor(low/step) * step; tick < high+step/2; tick += step {
+			ticks = append(ticks, tick)
+		}
+	}
+
+	// Ranges too vast to step through, or too narrow for their magnitude,
+	// are marked at their ends alone
+	if len(ticks) < 2 || !isFinite(ticks[0]) || !isFinite(ticks[len(ticks)-1]) {
+		return []float64{low, high}
+	}
+
+	return ticks
+}
+
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
+// Give every tick label the same number of decimal places
+func formatTick(tick float64, ticks []float64) string {
+	places := 0
+	if len(ticks) > 1 {
+		step := ticks[1] - ticks[0]
+		places = int(math.Max(0, -math.Floor(math.Log10(step)+1e-9)))
+	}
+
+	// Avoid printing "-0" for a tick which has drifted just below zero
+	if math.Abs(tick) < 1e-9 {
+		tick = 0
+	}
+
+	return strconv.FormatFloat(tick, 'f', places, 64)
+}
+
+func withAlpha(c color.Color, alpha float64) color.Color {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	nrgba.A = uint8(math.Round(float64(nrgba.A) * alpha))
+
+	return nrgba
+}
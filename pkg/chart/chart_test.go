@@ -0,0 +1,100 @@
+package chart
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+func TestNiceTicks(t *testing.T) {
+	ticks := niceTicks(-0.4, 2.9)
+	if len(ticks) != 5 || ticks[0] != -1 || ticks[4] != 3 {
+		t.Errorf("Expected ticks from -1 to 3-- got %v", ticks)
+		return
+	}
+
+	ticks = niceTicks(0, 0.07)
+	if label := formatTick(ticks[1], ticks); label != "0.02" {
+		t.Errorf("Expected labels with two decimal places-- got %s", label)
+		return
+	}
+
+	ranges := [][2]float64{
+		{0, math.NaN()},
+		{math.Inf(-1), 0},
+		{-1e308, 1e308},
+		{0, 1.7e308},
+		{1e300, 1e300},
+	}
+	for _, r := range ranges {
+		ticks := niceTicks(r[0], r[1])
+		if len(ticks) < 2 || math.IsNaN(ticks[0]) || math.IsInf(ticks[len(ticks)-1], 0) {
+			t.Errorf("Expected at least two finite ticks from %v to %v-- got %v", r[0], r[1], ticks)
+		}
+	}
+}
+
+func TestBarChart(t *testing.T) {
+	chart := types.NewChart()
+	chart.Categories = []string{"Q1", "Q2", "Q3"}
+	chart.Series = []types.ChartSeries{
+		{Name: "2023", Values: []float64{1, 2, 3}},
+		{Name: "2024", Values: []float64{2, 3, 4}},
+	}
+
+	svg := SVG(chart, "bars", 600, 400, 16, color.Black)
+	if count := strings.Count(svg, "<rect"); count != 8 {
+		t.Errorf("Expected six bars and two legend swatches-- got %d rects", count)
+		return
+	}
+
+	if !strings.Contains(svg, ">Q3</text>") || !strings.Contains(svg, ">2024</text>") {
+		t.Errorf("Expected category and series labels-- got %s", svg)
+	}
+}
+
+func TestLineChart(t *testing.T) {
+	chart := types.NewChart()
+	chart.Kind = types.LineChart
+	chart.Categories = []string{"Jan", "Feb"}
+	chart.Series = []types.ChartSeries{{Name: "Visitors", Values: []float64{10, 20}}}
+
+	svg := SVG(chart, "lines", 600, 400, 16, color.Black)
+	if strings.Count(svg, "<circle") != 2 || strings.Contains(svg, ">Visitors</text>") {
+		t.Errorf("Expected a marker for each point and no legend for a single series-- got %s", svg)
+	}
+}
+
+func TestPieChart(t *testing.T) {
+	chart := types.NewChart()
+	chart.Kind = types.PieChart
+	chart.Categories = []string{"Yes", "No", "Maybe"}
+	chart.Series = []types.ChartSeries{{Name: "Votes", Values: []float64{3, 1, 0}}}
+
+	svg := SVG(chart, "pie", 600, 400, 16, color.Black)
+	if count := strings.Count(svg, "<path"); count != 2 {
+		t.Errorf("Expected a slice for each non-zero value-- got %d", count)
+		return
+	}
+
+	if !strings.Contains(svg, "Yes (75%)") {
+		t.Errorf("Expected each category's share in the legend-- got %s", svg)
+	}
+}
+
+func TestHugeValues(t *testing.T) {
+	for _, kind := range []types.ChartKind{types.BarChart, types.LineChart, types.PieChart} {
+		chart := types.NewChart()
+		chart.Kind = kind
+		chart.Categories = []string{"Low", "High"}
+		chart.Series = []types.ChartSeries{{Name: "Extremes", Values: []float64{-1e308, 1.7e308}}}
+
+		if svg := SVG(chart, "huge", 600, 400, 16, color.Black); strings.Contains(svg, "NaN") || strings.Contains(svg, "Inf") {
+			t.Errorf("Expected a %s chart of huge values to be drawn-- got %s", kind, svg)
+			return
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package chart
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mbStavola/slydes/pkg/css"
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// A pie chart has a slice for each category of its first series, with a
+// legend to the right giving each category's share of the whole
+func arrangePie(layout *Layout, chart types.Chart, fontSize float64) {
+	if len(chart.Series) == 0 {
+		return
+	}
+
+	// Slices can't be negative, so those values are left out. The rest
+	// are scaled by the largest, so that huge values can't overflow the total
+	values := make([]float64, len(chart.Series[0].Values))
+	largest := 0.0
+	for i, value := range chart.Series[0].Values {
+		values[i] = math.Max(0, value)
+		largest = math.Max(largest, values[i])
+	}
+
+	total := 0.0
+	for i := range values {
+		if largest > 0 {
+			values[i] /= largest
+		}
+		total += values[i]
+	}
+
+	names := make([]string, len(chart.Categories))
+	legendWidth := 0.0
+	for i, category := range chart.Categories {
+		share := 0.0
+		if total > 0 {
+			share = values[i] / total * 100
+		}
+
+		names[i] = fmt.Sprintf("%s (%.0f%%)", category, share)
+		legendWidth = math.Max(legendWidth, fontSize*2+css.TextWidth(names[i], fontSize))
+	}
+
+	gap := fontSize / 2
+	radius := math.Max(0, math.Min(layout.Width-legendWidth-3*gap, layout.Height-2*gap)/2)
+	center := Point{gap + radius, layout.Height / 2}
+
+	angle := -math.Pi / 2
+	for i, value := range values {
+		if value == 0 {
+			continue
+		}
+
+		sweep := value / total * 2 * math.Pi
+		layout.add(Wedge{Center: center, Radius: radius, Start: angle, Sweep: sweep, Fill: chart.Color(i)})
+
+		angle += sweep
+	}
+
+	// The legend is a column of swatches, centered beside the pie
+	x := center.X + radius + 2*gap
+	y := center.Y - float64(len(names))*fontSize*1.5/2
+	for i, name := range names {
+		layout.add(
+			Rect{X: x, Y: y + fontSize*0.35, Width: fontSize * 0.8, Height: fontSize * 0.8, Fill: chart.Color(i)},
+			Label{Text: name, At: Point{x + fontSize, y + fontSize*0.75}, Baseline: Central},
+		)
+
+		y += fontSize * 1.5
+	}
+}
@@ -0,0 +1,101 @@
+package chart
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/mbStavola/slydes/pkg/css"
+)
+
+// SVG draws the layout with text of the given size.
+// The id must be unique within the document
+func (l Layout) SVG(id string, fontSize float64) string {
+	svg := strings.Builder{}
+	fmt.Fprintf(
+		&svg,
+		`<svg class="chart" id="%s" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %s %s" width="%s" height="%s" font-size="%s" fill="%s">`,
+		html.EscapeString(id), css.Number(l.Width), css.Number(l.Height), css.Number(l.Width), css.Number(l.Height), css.Number(fontSize), css.RGBA(l.Ink),
+	)
+
+	for _, mark := range l.Marks {
+		switch mark := mark.(type) {
+		case Rect:
+			fmt.Fprintf(
+				&svg,
+				`<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`,
+				css.Number(mark.X), css.Number(mark.Y), css.Number(mark.Width), css.Number(mark.Height), css.RGBA(mark.Fill),
+			)
+		case Polyline:
+			points := make([]string, len(mark.Points))
+			for i, point := range mark.Points {
+				points[i] = css.Number(point.X) + "," + css.Number(point.Y)
+			}
+
+			fmt.Fprintf(
+				&svg,
+				`<polyline points="%s" fill="none" stroke="%s" stroke-width="%s" stroke-linejoin="round"/>`,
+				strings.Join(points, " "), css.RGBA(mark.Stroke), css.Number(mark.Width),
+			)
+		case Circle:
+			fmt.Fprintf(
+				&svg,
+				`<circle cx="%s" cy="%s" r="%s" fill="%s"/>`,
+				css.Number(mark.Center.X), css.Number(mark.Center.Y), css.Number(mark.Radius), css.RGBA(mark.Fill),
+			)
+		case Wedge:
+			svg.WriteString(wedgeSVG(mark))
+		case Label:
+			svg.WriteString(labelSVG(mark))
+		}
+	}
+
+	svg.WriteString("</svg>")
+
+	return svg.String()
+}
+
+func wedgeSVG(wedge Wedge) string {
+	// A slice making up the whole pie can't be drawn as an arc
+	if wedge.Sweep >= 2*math.Pi-1e-9 {
+		return fmt.Sprintf(
+			`<circle cx="%s" cy="%s" r="%s" fill="%s"/>`,
+			css.Number(wedge.Center.X), css.Number(wedge.Center.Y), css.Number(wedge.Radius), css.RGBA(wedge.Fill),
+		)
+	}
+
+	large := 0
+	if wedge.Sweep > math.Pi {
+		large = 1
+	}
+
+	cx, cy, r := wedge.Center.X, wedge.Center.Y, wedge.Radius
+	end := wedge.Start + wedge.Sweep
+
+	return fmt.Sprintf(
+		`<path d="M %s %s L %s %s A %s %s 0 %d 1 %s %s Z" fill="%s"/>`,
+		css.Number(cx), css.Number(cy),
+		css.Number(cx+r*math.Cos(wedge.Start)), css.Number(cy+r*math.Sin(wedge.Start)),
+		css.Number(r), css.Number(r), large,
+		css.Number(cx+r*math.Cos(end)), css.Number(cy+r*math.Sin(end)),
+		css.RGBA(wedge.Fill),
+	)
+}
+
+var (
+	anchors   = []string{"start", "middle", "end"}
+	baselines = []string{"hanging", "central", "text-after-edge"}
+)
+
+func labelSVG(label Label) string {
+	position := fmt.Sprintf(`x="%s" y="%s"`, css.Number(label.At.X), css.Number(label.At.Y))
+	if label.Vertical {
+		position = fmt.Sprintf(`transform="translate(%s %s) rotate(-90)"`, css.Number(label.At.X), css.Number(label.At.Y))
+	}
+
+	return fmt.Sprintf(
+		`<text %s text-anchor="%s" dominant-baseline="%s">%s</text>`,
+		position, anchors[label.Anchor], baselines[label.Baseline], html.EscapeString(label.Text),
+	)
+}
@@ -0,0 +1,618 @@
+// Package convert translates presentations written in other formats into Sly
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mbStavola/slydes/pkg/highlight"
+)
+
+// Markdown converts a Markdown deck into Sly, using the light theme's
+// masters and styles. Slides are separated by lines of three dashes, as
+// in Marp or Remark, and a leading block of YAML front matter may give
+// the deck's title, author, date and description.
+//
+// A slide holding just a heading (and perhaps a subtitle) becomes a title
+// slide. On other slides, headings use the heading style, paragraphs and
+// lists become text blocks, fenced code becomes code blocks and pipe tables
+// become tables. Images become the slide's background, since Sly has no
+// images of its own. Speaker notes may follow ??? (Remark) or Note:
+// (reveal.js), or be written in HTML comments (Marp).
+//
+// Three dashes in a row are written as an em dash in words, but code
+// can't hold them in Sly at all, so such code is left out with a comment
+// saying so. Remote images are left out in the same way.
+func Markdown(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	lines := strings.Split(source, "\n")
+
+	metadata, lines := frontMatter(lines)
+
+	out := strings.Builder{}
+	out.WriteString("# Converted from Markdown by slydes import\n")
+	out.WriteString("@theme = \"light\";\n")
+	for _, key := range []string{"title", "author", "date", "description", "lang"} {
+		if value, ok := metadata[key]; ok {
+			fmt.Fprintf(&out, "@%s = %s;\n", key, quote(value))
+		}
+	}
+
+	names := map[string]bool{
+		// Slides can't share a name with the theme's masters,
+		// or slides inheriting from them would find the slide instead
+		"default": true,
+		"title":   true,
+		"content": true,
+	}
+
+	for i, lines := range splitSlides(lines) {
+		s := parseSlide(lines)
+		if s.isEmpty() {
+			continue
+		}
+
+		out.WriteString("\n")
+		s.write(&out, uniqueName(s.name(i+1), names))
+	}
+
+	return out.String()
+}
+
+type elementKind int
+
+const (
+	headingElement elementKind = iota
+	textElement
+	codeElement
+	tableElement
+)
+
+type element struct {
+	kind elementKind
+	// The text of a heading, text or code element
+	text     string
+	language string
+	rows     [][]string
+	// The justification of each column of a table, empty for the default
+	alignments []string
+}
+
+type slide struct {
+	elements      []element
+	background    string
+	backgroundFit string
+	// Why each part of the slide which has no place in Sly was left out
+	omissions []string
+	notes     []string
+}
+
+func (s slide) isEmpty() bool {
+	return len(s.elements) == 0 && s.background == "" && len(s.omissions) == 0 && len(s.notes) == 0
+}
+
+// Slides are named after their first heading, where there is one
+func (s slide) name(number int) string {
+	for _, element := range s.elements {
+		if element.kind != headingElement {
+			continue
+		}
+
+		words := strings.FieldsFunc(element.text, func(char rune) bool {
+			return !unicode.IsLetter(char) && !unicode.IsNumber(char)
+		})
+
+		name := strings.Builder{}
+		for _, word := range words {
+			if name.Len() == 0 {
+				name.WriteString(strings.ToLower(word))
+			} else {
+				name.WriteString(strings.ToUpper(word[:1]) + strings.ToLower(word[1:]))
+			}
+		}
+
+		if name.Len() > 0 && unicode.IsLetter([]rune(name.String())[0]) {
+			return name.String()
+		}
+
+		break
+	}
+
+	return fmt.Sprintf("slide%d", number)
+}
+
+// Words which can't name a slide, since the lexer reads them as keywords
+var keywords = map[string]bool{
+	"let": true, "mut": true, "macro": true, "master": true, "slide": true,
+	"block": true, "code": true, "table": true, "diagram": true, "chart": true,
+	"rect": true, "ellipse": true, "line": true, "arrow": true, "style": true,
+	"self": true, "true": true, "false": true,
+}
+
+func uniqueName(name string, taken map[string]bool) string {
+	unique := name
+	for i := 2; taken[unique] || keywords[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+
+	taken[unique] = true
+
+	return unique
+}
+
+// A slide is a title slide when it holds a heading, optionally followed
+// by a second heading or a short paragraph to use as its subtitle
+func (s slide) isTitle() bool {
+	if len(s.elements) == 0 || len(s.elements) > 2 || s.elements[0].kind != headingElement {
+		return false
+	}
+
+	if len(s.elements) == 2 {
+		subtitle := s.elements[1]
+		return subtitle.kind == headingElement || (subtitle.kind == textElement && !strings.Contains(subtitle.text, "\n"))
+	}
+
+	return true
+}
+
+func (s slide) write(out *strings.Builder, name string) {
+	if s.isTitle() {
+		fmt.Fprintf(out, "slide %s : title {\n", name)
+	} else {
+		fmt.Fprintf(out, "slide %s {\n", name)
+	}
+
+	// Blocks are separated by blank lines, as are any attributes before them
+	separate := false
+	if s.background != "" {
+		separate = true
+		fmt.Fprintf(out, "    self.backgroundImage = %s;\n", quote(s.background))
+		fmt.Fprintf(out, "    self.backgroundFit = %s;\n", quote(s.backgroundFit))
+	}
+
+	for _, omission := range s.omissions {
+		separate = true
+		fmt.Fprintf(out, "    # %s\n", omission)
+	}
+
+	if len(s.notes) > 0 {
+		separate = true
+		fmt.Fprintf(out, "    self.notes = %s;\n", text(words(strings.Join(s.notes, "\n\n")), "    "))
+	}
+
+	if s.isTitle() {
+		blocks := []string{"title", "subtitle"}
+		for i, element := range s.elements {
+			if separate {
+				out.WriteString("\n")
+			}
+
+			fmt.Fprintf(out, "    block %s {\n        %s\n    }\n", blocks[i], text(words(element.text), "        "))
+			separate = true
+		}
+
+		out.WriteString("}\n")
+		return
+	}
+
+	counts := make(map[elementKind]int)
+	for _, element := range s.elements {
+		counts[element.kind]++
+		count := counts[element.kind]
+
+		if separate {
+			out.WriteString("\n")
+		}
+		separate = true
+
+		switch element.kind {
+		case headingElement:
+			name := "title"
+			if count > 1 {
+				name = fmt.Sprintf("heading%d", count)
+			}
+
+			fmt.Fprintf(out, "    block %s : heading {\n", name)
+		case textElement:
+			fmt.Fprintf(out, "    block %s {\n", numbered("body", count))
+		case codeElement:
+			fmt.Fprintf(out, "    code %s {\n", numbered("example", count))
+			if highlight.Supported(element.language) {
+				fmt.Fprintf(out, "        self.language = %s;\n", quote(element.language))
+			}
+		case tableElement:
+			fmt.Fprintf(out, "    table %s {\n", numbered("data", count))
+			if alignments := strings.Join(element.alignments, ", "); strings.Trim(alignments, ", ") != "" {
+				fmt.Fprintf(out, "        self.columnAlign = %s;\n", quote(alignments))
+			}
+		}
+
+		if element.kind == tableElement {
+			fmt.Fprintf(out, "        %s\n", text(words(csvText(element.rows)), "        "))
+		} else if element.kind == codeElement {
+			fmt.Fprintf(out, "        %s\n", text(element.text, "        "))
+		} else {
+			fmt.Fprintf(out, "        %s\n", text(words(element.text), "        "))
+		}
+
+		out.WriteString("    }\n")
+	}
+
+	out.WriteString("}\n")
+}
+
+func numbered(name string, count int) string {
+	if count == 1 {
+		return name
+	}
+
+	return fmt.Sprintf("%s%d", name, count)
+}
+
+// Write text as a text literal, on one line when it fits
+// or else on lines of its own between the dashes
+func text(value string, indent string) string {
+	// Dashes at either end would run into the ones around the text
+	if !strings.Contains(value, "\n") && !strings.HasPrefix(value, "-") && !strings.HasSuffix(value, "-") {
+		return "---" + value + "---"
+	}
+
+	lines := strings.Split(value, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+
+	return "---\n" + strings.Join(lines, "\n") + "\n" + indent + "---"
+}
+
+// Three dashes in a row would end a text literal early, so in words
+// they are written as the em dash they stand for
+func words(value string) string {
+	return strings.ReplaceAll(value, "---", "—")
+}
+
+// Sly strings can't contain double quotes, so any are made single
+func quote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "'") + `"`
+}
+
+// Write rows as comma separated values, quoting cells as a CSV file would
+func csvText(rows [][]string) string {
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			if strings.ContainsAny(cell, `,"`) {
+				cell = `"` + strings.ReplaceAll(cell, `"`, `""`) + `"`
+			}
+
+			cells[j] = cell
+		}
+
+		lines[i] = strings.Join(cells, ", ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Read the YAML front matter at the start of a deck, if it has any,
+// returning its simple fields along with the lines following it
+func frontMatter(lines []string) (map[string]string, []string) {
+	metadata := make(map[string]string)
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return metadata, lines
+	}
+
+	for i, line := range lines[1:] {
+		if strings.TrimSpace(line) != "---" {
+			continue
+		}
+
+		// Anything but a mapping means the dashes were the first separator
+		document := yaml.Node{}
+		if err := yaml.Unmarshal([]byte(strings.Join(lines[1:i+1], "\n")), &document); err != nil || len(document.Content) == 0 {
+			break
+		}
+
+		mapping := document.Content[0]
+		if mapping.Kind != yaml.MappingNode || len(mapping.Content) == 0 {
+			break
+		}
+
+		// Lists and other nested fields have nothing to become
+		for j := 0; j+1 < len(mapping.Content); j += 2 {
+			key, value := mapping.Content[j], mapping.Content[j+1]
+			if value.Kind == yaml.ScalarNode && value.Tag != "!!null" {
+				metadata[key.Value] = value.Value
+			}
+		}
+
+		return metadata, lines[i+2:]
+	}
+
+	return map[string]string{}, lines
+}
+
+var fence = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+#-]*)")
+
+// Split a deck into the lines of each slide, ignoring separators in code
+func splitSlides(lines []string) [][]string {
+	slides := make([][]string, 0)
+	current := make([]string, 0)
+	inCode := ""
+
+	for _, line := range lines {
+		if match := fence.FindStringSubmatch(line); match != nil {
+			if inCode == "" {
+				inCode = match[1]
+			} else if strings.HasPrefix(match[1], inCode) && match[2] == "" {
+				inCode = ""
+			}
+		}
+
+		if inCode == "" && strings.TrimSpace(line) == "---" {
+			slides = append(slides, current)
+			current = make([]string, 0)
+			continue
+		}
+
+		current = append(current, line)
+	}
+
+	return append(slides, current)
+}
+
+var (
+	heading      = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	listItem     = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	image        = regexp.MustCompile(`^!\[([^\]]*)\]\(\s*([^)\s]+)(?:\s+"[^"]*")?\s*\)$`)
+	tableDivider = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+	remarkField  = regexp.MustCompile(`^(name|class|layout|template|count|exclude|background-image)\s*:\s*(.*)$`)
+	cssURL       = regexp.MustCompile(`url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+	marpField    = regexp.MustCompile(`^_?(theme|paginate|header|footer|class|backgroundColor|backgroundImage|backgroundPosition|backgroundRepeat|backgroundSize|color|size|style|headingDivider|math|marp|title|author|description|image|url|lang|transition)\s*:`)
+)
+
+func parseSlide(lines []string) slide {
+	s := slide{}
+	words := make([]string, 0)
+
+	// What the last line of text was, so that a paragraph's lines
+	// can be joined and a list item can continue onto the next line
+	const (
+		none = iota
+		paragraph
+		item
+	)
+	last := none
+
+	flush := func() {
+		for len(words) > 0 && words[len(words)-1] == "" {
+			words = words[:len(words)-1]
+		}
+
+		if len(words) > 0 {
+			s.elements = append(s.elements, element{kind: textElement, text: strings.Join(words, "\n")})
+		}
+
+		words = make([]string, 0)
+		last = none
+	}
+
+	// Remark's slide properties come before anything else on the slide
+	for len(lines) > 0 {
+		match := remarkField.FindStringSubmatch(strings.TrimSpace(lines[0]))
+		if match == nil {
+			break
+		}
+
+		if url := cssURL.FindStringSubmatch(match[2]); match[1] == "background-image" && url != nil {
+			s.background, s.backgroundFit = url[1], "cover"
+		}
+
+		lines = lines[1:]
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "???" || strings.HasPrefix(trimmed, "Note:"):
+			// Everything after this is the speaker's
+			flush()
+
+			notes := lines[i+1:]
+			if trimmed != "???" {
+				notes = append([]string{strings.TrimSpace(trimmed[strings.Index(trimmed, ":")+1:])}, notes...)
+			}
+
+			if note := strings.TrimSpace(strings.Join(notes, "\n")); note != "" {
+				s.notes = append(s.notes, note)
+			}
+
+			return s
+		case strings.HasPrefix(trimmed, "<!--"):
+			comment := strings.TrimPrefix(trimmed, "<!--")
+			for !strings.Contains(comment, "-->") && i+1 < len(lines) {
+				i++
+				comment += "\n" + lines[i]
+			}
+
+			comment = strings.TrimSpace(comment[:strings.Index(comment+"-->", "-->")])
+			if comment != "" && !marpField.MatchString(comment) {
+				s.notes = append(s.notes, comment)
+			}
+		case fence.MatchString(line):
+			flush()
+
+			match := fence.FindStringSubmatch(line)
+			code := make([]string, 0)
+			for i++; i < len(lines); i++ {
+				if closing := fence.FindStringSubmatch(lines[i]); closing != nil && strings.HasPrefix(closing[1], match[1]) && closing[2] == "" {
+					break
+				}
+
+				code = append(code, lines[i])
+			}
+
+			// Three dashes in a row would end the code's text early
+			if text := strings.Join(code, "\n"); strings.Contains(text, "---") {
+				s.omissions = append(s.omissions, "Sly text can't hold three dashes in a row, so a block of code holding them was left out")
+			} else {
+				s.elements = append(s.elements, element{
+					kind:     codeElement,
+					text:     text,
+					language: strings.ToLower(match[2]),
+				})
+			}
+		case heading.MatchString(trimmed):
+			flush()
+
+			s.elements = append(s.elements, element{kind: headingElement, text: inline(heading.FindStringSubmatch(trimmed)[2])})
+		case image.MatchString(trimmed):
+			match := image.FindStringSubmatch(trimmed)
+			s.addImage(match[1], match[2])
+		case strings.HasPrefix(trimmed, "|"):
+			flush()
+
+			table := element{kind: tableElement}
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				row := strings.TrimSpace(lines[i])
+				if tableDivider.MatchString(row) {
+					table.alignments = columnAlignments(row)
+					continue
+				}
+
+				table.rows = append(table.rows, tableCells(row))
+			}
+			i--
+
+			s.elements = append(s.elements, table)
+		case trimmed == "" || trimmed == "--":
+			// Remark's -- reveals the rest of a slide one step at a time,
+			// which Sly doesn't do, so it's treated as a blank line
+			if len(words) > 0 && words[len(words)-1] != "" {
+				words = append(words, "")
+			}
+
+			last = none
+		case listItem.MatchString(line):
+			match := listItem.FindStringSubmatch(line)
+			marker := match[2]
+			if strings.ContainsAny(marker, "-*+") {
+				marker = "•"
+			}
+
+			depth := len(strings.ReplaceAll(match[1], "\t", "  ")) / 2
+			words = append(words, strings.Repeat("  ", depth)+marker+" "+inline(match[3]))
+			last = item
+		default:
+			content := inline(strings.TrimPrefix(strings.TrimPrefix(trimmed, ">"), " "))
+			if (last == paragraph || last == item) && len(words) > 0 {
+				words[len(words)-1] += " " + content
+			} else {
+				words = append(words, content)
+				last = paragraph
+			}
+		}
+	}
+
+	flush()
+
+	return s
+}
+
+// Images with "bg" in their alt text are backgrounds in Marp, which may
+// also say how they are fit. Any other image becomes the background too,
+// fit within the slide, unless the slide already has one. Images which
+// aren't local files are left out
+func (s *slide) addImage(alt string, path string) {
+	words := strings.Fields(alt)
+
+	fit := "fit"
+	isBackground := false
+	for _, word := range words {
+		switch word {
+		case "bg":
+			isBackground = true
+			fit = "cover"
+		case "contain", "fit":
+			fit = "fit"
+		case "repeat":
+			fit = "tile"
+		}
+	}
+
+	// Sly only reads images from files
+	if strings.Contains(path, "://") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "data:") {
+		s.omissions = append(s.omissions, fmt.Sprintf("Sly has no images from the web, so %s was left out", path))
+		return
+	}
+
+	if s.background != "" && !isBackground {
+		s.omissions = append(s.omissions, fmt.Sprintf("Sly has no images outside of backgrounds, so %s was left out", path))
+		return
+	}
+
+	s.background, s.backgroundFit = path, fit
+}
+
+func tableCells(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+
+	// Pipes may be escaped to appear within a cell
+	cells := strings.Split(strings.ReplaceAll(row, `\|`, "\x00"), "|")
+	for i, cell := range cells {
+		cells[i] = inline(strings.ReplaceAll(strings.TrimSpace(cell), "\x00", "|"))
+	}
+
+	return cells
+}
+
+func columnAlignments(divider string) []string {
+	divider = strings.TrimSuffix(strings.TrimPrefix(divider, "|"), "|")
+
+	columns := strings.Split(divider, "|")
+	alignments := make([]string, len(columns))
+	for i, column := range columns {
+		column = strings.TrimSpace(column)
+		switch {
+		case strings.HasPrefix(column, ":") && strings.HasSuffix(column, ":"):
+			alignments[i] = "center"
+		case strings.HasSuffix(column, ":"):
+			alignments[i] = "right"
+		default:
+			alignments[i] = "left"
+		}
+	}
+
+	return alignments
+}
+
+var (
+	inlineImage   = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	link          = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	strong        = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	emphasis      = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`)
+	strikethrough = regexp.MustCompile(`~~(.+?)~~`)
+	codeSpan      = regexp.MustCompile("`([^`]+)`")
+	escaped       = regexp.MustCompile(`\\([\\*_{}\[\]()#+\-.!|~>])`)
+)
+
+// Sly text is plain, so inline formatting is dropped, keeping its text
+func inline(text string) string {
+	text = inlineImage.ReplaceAllString(text, "$1")
+	text = link.ReplaceAllString(text, "$1")
+	text = strong.ReplaceAllString(text, "${1}${2}")
+	text = emphasis.ReplaceAllString(text, "$1")
+	text = strikethrough.ReplaceAllString(text, "$1")
+	text = codeSpan.ReplaceAllString(text, "$1")
+
+	return escaped.ReplaceAllString(text, "$1")
+}
@@ -0,0 +1,178 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mbStavola/slydes/pkg/lang"
+)
+
+const deck = `---
+title: "Quarterly Review"
+author: Ada Lovelace
+---
+
+# Quarterly Review
+## Q3 2026
+
+---
+
+# Results
+
+Revenue grew **12%**, driven by
+[enterprise](https://example.com) sales.
+
+- New customers
+  - Mostly in EMEA
+- Churn fell
+
+<!-- Thank the "sales" team -->
+
+---
+
+# Code
+
+` + "```go" + `
+func main() {
+	fmt.Println("Hello")
+}
+` + "```" + `
+
+| Quarter | Revenue |
+|:--------|--------:|
+| Q1      | 1,200   |
+
+???
+Keep this short
+`
+
+func TestMarkdown(t *testing.T) {
+	source := Markdown(deck)
+
+	show, err := lang.NewSly().ReadSlideShowString(source)
+	if err != nil {
+		t.Errorf("Expected the converted deck to compile-- got %s\n%s", err, source)
+		return
+	}
+
+	if show.Metadata.Title != "Quarterly Review" || show.Metadata.Author != "Ada Lovelace" {
+		t.Errorf("Expected the front matter to become directives-- got %+v", show.Metadata)
+		return
+	}
+
+	if len(show.Slides) != 3 {
+		t.Errorf("Expected three slides-- got %d", len(show.Slides))
+		return
+	}
+
+	title := show.Slides[0]
+	if len(title.Blocks) != 2 || title.Blocks[1].Words != "Q3 2026" {
+		t.Errorf("Expected a title slide with a subtitle-- got %+v", title.Blocks)
+		return
+	}
+
+	results := show.Slides[1]
+	if results.Notes != `Thank the "sales" team` {
+		t.Errorf("Expected the comment to become notes-- got %q", results.Notes)
+		return
+	}
+
+	body := strings.TrimSpace(results.Blocks[1].Words)
+	if !strings.HasPrefix(body, "Revenue grew 12%, driven by enterprise sales.") || !strings.Contains(body, "  • Mostly in EMEA") {
+		t.Errorf("Expected a paragraph followed by a nested list-- got %q", body)
+		return
+	}
+
+	code := show.Slides[2]
+	if code.Notes != "Keep this short" {
+		t.Errorf("Expected the notes after ???-- got %q", code.Notes)
+		return
+	}
+
+	if len(code.Blocks) != 3 || code.Blocks[1].Code == nil || code.Blocks[1].Code.Language != "go" {
+		t.Errorf("Expected a heading, a go code block and a table-- got %+v", code.Blocks)
+		return
+	}
+
+	table := code.Blocks[2].Table
+	if table == nil || len(table.Rows) != 2 || table.Rows[1][1] != "1,200" || len(table.ColumnAlignments) != 2 {
+		t.Errorf("Expected a table with a quoted cell and aligned columns-- got %+v", table)
+	}
+}
+
+func TestMarkdownImages(t *testing.T) {
+	source := Markdown("![bg repeat](tile.png)\n![](chart.png)\n\nText")
+
+	if !strings.Contains(source, `self.backgroundImage = "tile.png";`) || !strings.Contains(source, `self.backgroundFit = "tile";`) {
+		t.Errorf("Expected a tiled background-- got\n%s", source)
+		return
+	}
+
+	if !strings.Contains(source, "# Sly has no images outside of backgrounds, so chart.png was left out") {
+		t.Errorf("Expected a note about the second image-- got\n%s", source)
+	}
+}
+
+func TestSlideNames(t *testing.T) {
+	source := Markdown("# Code\n---\n# Code\n---\n# Title\n---\nNo heading")
+
+	for _, name := range []string{"slide code2 ", "slide code3 ", "slide title2 ", "slide slide4 "} {
+		if !strings.Contains(source, name) {
+			t.Errorf("Expected a slide declared as %q-- got\n%s", name, source)
+		}
+	}
+}
+
+func TestQuotedFrontMatter(t *testing.T) {
+	source := Markdown("---\ntitle: \"My \\\"Quoted\\\" Deck\"\nauthor: 'Ada''s'\ntags: [a, b]\n---\n# Hello")
+
+	for _, directive := range []string{`@title = "My 'Quoted' Deck";`, `@author = "Ada's";`} {
+		if !strings.Contains(source, directive) {
+			t.Errorf("Expected %s-- got\n%s", directive, source)
+		}
+	}
+}
+
+func TestDashes(t *testing.T) {
+	source := Markdown("# Before --- after\n\nWords --- and more\n\n```yaml\n---\nkey: value\n```\n\n```go\nx := 1\n```")
+
+	if !strings.Contains(source, "---Before — after---") || !strings.Contains(source, "---Words — and more---") {
+		t.Errorf("Expected dashes in words to become em dashes-- got\n%s", source)
+		return
+	}
+
+	show, err := lang.NewSly().ReadSlideShowString(source)
+	if err != nil {
+		t.Errorf("Expected the converted deck to compile-- got %s\n%s", err, source)
+		return
+	}
+
+	// Code holding dashes is left out, but the rest of the slide is kept
+	blocks := show.Slides[0].Blocks
+	if len(blocks) != 3 || blocks[2].Code == nil || strings.TrimSpace(blocks[2].Words) != "x := 1" {
+		t.Errorf("Expected the code with dashes to be left out-- got %+v", blocks)
+		return
+	}
+
+	if !strings.Contains(source, "# Sly text can't hold three dashes in a row") {
+		t.Errorf("Expected a comment about the code which was left out-- got\n%s", source)
+	}
+}
+
+func TestRemoteImages(t *testing.T) {
+	source := Markdown("# Logo\n\n![bg](https://example.com/logo.png)\n![](//example.com/other.png)")
+
+	if strings.Contains(source, "backgroundImage") {
+		t.Errorf("Expected no background from the web-- got\n%s", source)
+		return
+	}
+
+	if !strings.Contains(source, "# Sly has no images from the web, so https://example.com/logo.png was left out") {
+		t.Errorf("Expected a comment about the image which was left out-- got\n%s", source)
+		return
+	}
+
+	if _, err := lang.NewSly().ReadSlideShowString(source); err != nil {
+		t.Errorf("Expected the converted deck to compile-- got %s\n%s", err, source)
+	}
+}
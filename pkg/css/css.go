@@ -0,0 +1,31 @@
+// Package css writes the values which charts, diagrams and the
+// renderers share, in the form both CSS and SVG attributes take
+package css
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Number rounds to two decimal places to keep the markup short
+func Number(x float64) string {
+	return strconv.FormatFloat(math.Round(x*100)/100, 'f', -1, 64)
+}
+
+// RGBA writes a color with components which are not premultiplied
+// by alpha, with the alpha itself ranging from 0 to 1
+func RGBA(c color.Color) string {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	alpha := strconv.FormatFloat(math.Round(float64(nrgba.A)/255*1000)/1000, 'f', -1, 64)
+
+	return fmt.Sprintf("rgba(%d, %d, %d, %s)", nrgba.R, nrgba.G, nrgba.B, alpha)
+}
+
+// TextWidth measures text by assuming every character is a little over
+// half as wide as it is tall, which suits most proportional fonts
+func TextWidth(text string, fontSize float64) float64 {
+	return float64(utf8.RuneCountInString(text)) * fontSize * 0.6
+}
@@ -0,0 +1,27 @@
+package css
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestNumber(t *testing.T) {
+	for value, expected := range map[float64]string{1: "1", 1.005: "1", 2.345678: "2.35", -0.5: "-0.5"} {
+		if actual := Number(value); actual != expected {
+			t.Errorf("Expected %s for %v-- got %s", expected, value, actual)
+		}
+	}
+}
+
+func TestRGBA(t *testing.T) {
+	// Components must not be premultiplied by alpha
+	if actual := RGBA(color.NRGBA{R: 255, G: 0, B: 0, A: 128}); actual != "rgba(255, 0, 0, 0.502)" {
+		t.Errorf("Expected rgba(255, 0, 0, 0.502)-- got %s", actual)
+	}
+}
+
+func TestFontFamilies(t *testing.T) {
+	if actual := FontFamilies([]string{`Fira "Sans"`, "ui-monospace"}); actual != `"Fira \"Sans\"", ui-monospace` {
+		t.Errorf(`Expected "Fira \"Sans\"", ui-monospace-- got %s`, actual)
+	}
+}
@@ -0,0 +1,73 @@
+package css
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// Families which CSS defines itself, and which must not be quoted
+var genericFamilies = map[string]bool{
+	"serif":         true,
+	"sans-serif":    true,
+	"monospace":     true,
+	"cursive":       true,
+	"fantasy":       true,
+	"system-ui":     true,
+	"ui-serif":      true,
+	"ui-sans-serif": true,
+	"ui-monospace":  true,
+	"ui-rounded":    true,
+	"emoji":         true,
+	"math":          true,
+	"fangsong":      true,
+}
+
+// FontFamilies writes a font stack, quoting every family but the generic ones
+func FontFamilies(families []string) string {
+	quoted := make([]string, 0, len(families))
+	for _, family := range families {
+		if genericFamilies[family] {
+			quoted = append(quoted, family)
+		} else {
+			quoted = append(quoted, String(family))
+		}
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// String quotes text as a CSS string
+func String(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `).Replace(text) + `"`
+}
+
+// The media types and CSS formats of the font files a show may embed
+var fontFormats = map[string][2]string{
+	".woff2": {"font/woff2", "woff2"},
+	".woff":  {"font/woff", "woff"},
+	".ttf":   {"font/ttf", "truetype"},
+	".otf":   {"font/otf", "opentype"},
+}
+
+// FontFace writes an @font-face rule with the font file embedded in it
+func FontFace(face types.FontFace) (string, error) {
+	data, err := ioutil.ReadFile(face.Path)
+	if err != nil {
+		return "", err
+	}
+
+	format, ok := fontFormats[strings.ToLower(filepath.Ext(face.Path))]
+	if !ok {
+		return "", fmt.Errorf("unsupported font file %q", face.Path)
+	}
+
+	return fmt.Sprintf(
+		"@font-face { font-family: %s; src: url(\"data:%s;base64,%s\") format(\"%s\"); }",
+		String(face.Family), format[0], base64.StdEncoding.EncodeToString(data), format[1],
+	), nil
+}
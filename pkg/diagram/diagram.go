@@ -0,0 +1,131 @@
+// Package diagram reads the text of diagram blocks, and lays
+// diagrams out so that they can be drawn by any renderer
+//
+// A diagram is written one statement per line:
+//
+//	# Comments start with a hash
+//	web: Web browser
+//	web -> api: GET /slides
+//	api -> db -> api
+//	api --> web: 200 OK
+//
+// A line with an arrow joins nodes, with an optional label after a colon.
+// A dashed arrow (-->) draws a dashed line. Any other line gives a node a
+// label, which otherwise defaults to the name it is referred to by.
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// Parse adds the nodes and edges described by text to the diagram
+func Parse(text string, diagram *types.Diagram) error {
+	nodes := make(map[string]int)
+	for i, node := range diagram.Nodes {
+		nodes[node.ID] = i
+	}
+
+	declare := func(id string) {
+		if _, ok := nodes[id]; !ok {
+			nodes[id] = len(diagram.Nodes)
+			diagram.Nodes = append(diagram.Nodes, types.DiagramNode{ID: id, Label: id})
+		}
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		statement, label := line, ""
+		if colon := strings.Index(line, ":"); colon >= 0 {
+			statement, label = strings.TrimSpace(line[:colon]), strings.TrimSpace(line[colon+1:])
+		}
+
+		if !strings.Contains(statement, "->") {
+			if statement == "" {
+				return fmt.Errorf("line %d: expected a node before the colon", i+1)
+			}
+
+			declare(statement)
+			if label != "" {
+				diagram.Nodes[nodes[statement]].Label = label
+			}
+
+			continue
+		}
+
+		// Split a chain of arrows (ex: a -> b --> c) into
+		// its nodes, remembering which arrows were dashed
+		ids := make([]string, 0, 2)
+		dashed := make([]bool, 0, 1)
+		for {
+			arrow := strings.Index(statement, "->")
+			if arrow < 0 {
+				ids = append(ids, strings.TrimSpace(statement))
+				break
+			}
+
+			isDashed := arrow > 0 && statement[arrow-1] == '-'
+			end := arrow
+			if isDashed {
+				end--
+			}
+
+			ids = append(ids, strings.TrimSpace(statement[:end]))
+			dashed = append(dashed, isDashed)
+			statement = statement[arrow+2:]
+		}
+
+		for _, id := range ids {
+			if id == "" {
+				return fmt.Errorf("line %d: every arrow must join two nodes", i+1)
+			}
+		}
+
+		for j, id := range ids {
+			declare(id)
+
+			if j > 0 {
+				diagram.Edges = append(diagram.Edges, types.DiagramEdge{
+					From:   ids[j-1],
+					To:     id,
+					Label:  label,
+					Dashed: dashed[j-1],
+				})
+			}
+		}
+	}
+
+	return Validate(*diagram)
+}
+
+// Validate checks that a diagram has nodes, and that its edges join them
+func Validate(diagram types.Diagram) error {
+	if len(diagram.Nodes) == 0 {
+		return fmt.Errorf("a diagram needs at least one node")
+	}
+
+	nodes := make(map[string]bool)
+	for _, node := range diagram.Nodes {
+		if nodes[node.ID] {
+			return fmt.Errorf("node %q is declared twice", node.ID)
+		}
+
+		nodes[node.ID] = true
+	}
+
+	for _, edge := range diagram.Edges {
+		for _, id := range []string{edge.From, edge.To} {
+			if !nodes[id] {
+				return fmt.Errorf("an arrow joins %q, which is not a node", id)
+			}
+		}
+	}
+
+	return nil
+}
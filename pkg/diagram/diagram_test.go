@@ -0,0 +1,161 @@
+package diagram
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+func TestParse(t *testing.T) {
+	text := `
+	# A comment
+	a: Start
+	a -> b --> c: next
+	c
+	`
+
+	diagram := types.Diagram{}
+	if err := Parse(text, &diagram); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(diagram.Nodes) != 3 || diagram.Nodes[0].Label != "Start" || diagram.Nodes[2].Label != "c" {
+		t.Errorf("Expected three nodes with a labelled start-- got %+v", diagram.Nodes)
+		return
+	}
+
+	if len(diagram.Edges) != 2 {
+		t.Errorf("Expected two edges-- got %+v", diagram.Edges)
+		return
+	}
+
+	first, second := diagram.Edges[0], diagram.Edges[1]
+	if first.From != "a" || first.To != "b" || first.Dashed || first.Label != "next" {
+		t.Errorf("Expected a solid, labelled edge from a to b-- got %+v", first)
+		return
+	}
+
+	if second.From != "b" || second.To != "c" || !second.Dashed {
+		t.Errorf("Expected a dashed edge from b to c-- got %+v", second)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	texts := []string{
+		"a ->",
+		"-> b",
+		"a -> -> b",
+		": Label",
+		"",
+		"# Only a comment",
+	}
+
+	for _, text := range texts {
+		if err := Parse(text, &types.Diagram{}); err == nil {
+			t.Errorf("Expected an error for %q", text)
+		}
+	}
+}
+
+func TestFlowchartLayout(t *testing.T) {
+	diagram := types.Diagram{}
+	if err := Parse("a -> b -> c\nc -> a", &diagram); err != nil {
+		t.Error(err)
+		return
+	}
+
+	layout := Arrange(diagram, 16)
+	if len(layout.Boxes) != 3 || len(layout.Connectors) != 3 {
+		t.Errorf("Expected three boxes and three connectors-- got %d and %d", len(layout.Boxes), len(layout.Connectors))
+		return
+	}
+
+	for i := 1; i < len(layout.Boxes); i++ {
+		if layout.Boxes[i].Y <= layout.Boxes[i-1].Y {
+			t.Errorf("Expected each node in a chain to sit below the last-- got %+v", layout.Boxes)
+			return
+		}
+	}
+
+	for _, box := range layout.Boxes {
+		if box.X < 0 || box.Y < 0 || box.X+box.Width > layout.Width || box.Y+box.Height > layout.Height {
+			t.Errorf("Expected every box to fit in the diagram-- got %+v in %vx%v", box, layout.Width, layout.Height)
+			return
+		}
+	}
+}
+
+func TestEmptyLayout(t *testing.T) {
+	directions := []types.DiagramDirection{types.TopToBottom, types.LeftToRight}
+	for _, direction := range directions {
+		layout := Arrange(types.Diagram{Direction: direction}, 16)
+		if layout.Width != 0 || layout.Height != 0 {
+			t.Errorf("Expected an empty diagram to take no space-- got %vx%v", layout.Width, layout.Height)
+			return
+		}
+	}
+
+	if layout := Arrange(types.Diagram{Kind: types.Sequence}, 16); layout.Width != 0 || layout.Height != 0 {
+		t.Errorf("Expected an empty sequence to take no space-- got %vx%v", layout.Width, layout.Height)
+	}
+}
+
+func TestSequenceLayout(t *testing.T) {
+	diagram := types.Diagram{Kind: types.Sequence}
+	if err := Parse("a -> b: ask\nb --> a: answer", &diagram); err != nil {
+		t.Error(err)
+		return
+	}
+
+	layout := Arrange(diagram, 16)
+	svg := layout.SVG("test", 16, "", color.Black)
+	if !strings.Contains(svg, `id="test-arrow"`) || !strings.Contains(svg, "answer") {
+		t.Errorf("Expected an arrow marker and message labels-- got %s", svg)
+	}
+}
+
+func TestBackEdgeRouting(t *testing.T) {
+	diagram := types.Diagram{}
+	if err := Parse("a -> b -> c\nc -> a", &diagram); err != nil {
+		t.Error(err)
+		return
+	}
+
+	layout := Arrange(diagram, 16)
+	back := layout.Connectors[2]
+	if len(back.Points) != 4 {
+		t.Errorf("Expected the edge back to the start to be routed around-- got %+v", back.Points)
+		return
+	}
+
+	for _, box := range layout.Boxes {
+		if back.Points[1].X <= box.X+box.Width {
+			t.Errorf("Expected the routed edge to clear every box-- got %+v", back.Points)
+			return
+		}
+	}
+}
+
+func TestRoutedLabelsClearNodes(t *testing.T) {
+	diagram := types.Diagram{Direction: types.LeftToRight}
+	if err := Parse("a -> b -> c\nc -> a: back", &diagram); err != nil {
+		t.Error(err)
+		return
+	}
+
+	layout := Arrange(diagram, 16)
+	label := layout.Texts[len(layout.Texts)-1]
+	for _, box := range layout.Boxes {
+		if label.At.Y-8 <= box.Y+box.Height {
+			t.Errorf("Expected the label below every box-- got %+v", label)
+			return
+		}
+	}
+
+	if label.At.Y+8 > layout.Height {
+		t.Errorf("Expected the diagram to make room for the label-- got %+v in %v", label, layout.Height)
+	}
+}
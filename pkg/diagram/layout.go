@@ -0,0 +1,529 @@
+package diagram
+
+import (
+	"math"
+	"sort"
+
+	"github.com/mbStavola/slydes/pkg/css"
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+type Point struct {
+	X float64
+	Y float64
+}
+
+// A Box is drawn around a node's label
+type Box struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// A Connector is a line through its points, optionally
+// ending in an arrow head at the last of them
+type Connector struct {
+	Points []Point
+	Dashed bool
+	Arrow  bool
+}
+
+// A Text is a single line of text, centered on its position
+type Text struct {
+	Text string
+	At   Point
+}
+
+// A Layout is a diagram arranged into shapes, measured in pixels
+// with (0, 0) being the top left corner
+type Layout struct {
+	Width      float64
+	Height     float64
+	Boxes      []Box
+	Connectors []Connector
+	Texts      []Text
+}
+
+// Arrange lays out a diagram for text of the given size. A diagram
+// without any nodes has nothing to draw, and so takes up no space
+func Arrange(diagram types.Diagram, fontSize float64) Layout {
+	if len(diagram.Nodes) == 0 {
+		return Layout{}
+	}
+
+	if diagram.Kind == types.Sequence {
+		return arrangeSequence(diagram, fontSize)
+	}
+
+	return arrangeFlowchart(diagram, fontSize)
+}
+
+// Measurements shared by both kinds of diagram
+type metrics struct {
+	fontSize   float64
+	padding    float64
+	boxHeight  float64
+	margin     float64
+	gap        float64
+	labelSpace float64
+}
+
+func newMetrics(fontSize float64) metrics {
+	return metrics{
+		fontSize:   fontSize,
+		padding:    fontSize,
+		boxHeight:  fontSize * 2.2,
+		margin:     fontSize / 2,
+		gap:        fontSize * 2,
+		labelSpace: fontSize * 1.5,
+	}
+}
+
+func (m metrics) boxWidth(label string) float64 {
+	return math.Max(css.TextWidth(label, m.fontSize)+2*m.padding, m.boxHeight*2)
+}
+
+// Flowcharts are drawn in layers, where each node sits one layer
+// past the furthest node pointing at it. Nodes within a layer are
+// ordered to sit near their neighbours, cutting down on crossings
+func arrangeFlowchart(diagram types.Diagram, fontSize float64) Layout {
+	m := newMetrics(fontSize)
+
+	index := make(map[string]int, len(diagram.Nodes))
+	for i, node := range diagram.Nodes {
+		index[node.ID] = i
+	}
+
+	ranks := rankNodes(diagram, index)
+
+	layers := make([][]int, 0)
+	for i, rank := range ranks {
+		for len(layers) <= rank {
+			layers = append(layers, make([]int, 0))
+		}
+
+		layers[rank] = append(layers[rank], i)
+	}
+
+	orderLayers(diagram, index, layers)
+
+	// Any label on an edge widens the gap between layers to make room for it
+	gap := m.gap
+	for _, edge := range diagram.Edges {
+		if edge.Label != "" {
+			gap += m.labelSpace
+			break
+		}
+	}
+
+	// Measure each layer along the direction of flow (its thickness)
+	// and across it (its length), so the layers can be centered
+	sizes := make([]Point, len(diagram.Nodes))
+	for i, node := range diagram.Nodes {
+		sizes[i] = Point{X: m.boxWidth(node.Label), Y: m.boxHeight}
+	}
+
+	across := func(i int) float64 {
+		if diagram.Direction == types.LeftToRight {
+			return sizes[i].Y
+		}
+
+		return sizes[i].X
+	}
+	along := func(i int) float64 {
+		if diagram.Direction == types.LeftToRight {
+			return sizes[i].X
+		}
+
+		return sizes[i].Y
+	}
+
+	thickness := make([]float64, len(layers))
+	lengths := make([]float64, len(layers))
+	longest := 0.0
+	for r, layer := range layers {
+		for j, i := range layer {
+			thickness[r] = math.Max(thickness[r], along(i))
+			lengths[r] += across(i)
+			if j > 0 {
+				lengths[r] += m.gap
+			}
+		}
+
+		longest = math.Max(longest, lengths[r])
+	}
+
+	layout := Layout{}
+	centers := make([]Point, len(diagram.Nodes))
+	boxes := make([]Box, len(diagram.Nodes))
+
+	position := m.margin
+	for r, layer := range layers {
+		offset := m.margin + (longest-lengths[r])/2
+		for _, i := range layer {
+			mid := position + thickness[r]/2
+			center := offset + across(i)/2
+
+			if diagram.Direction == types.LeftToRight {
+				centers[i] = Point{X: mid, Y: center}
+			} else {
+				centers[i] = Point{X: center, Y: mid}
+			}
+
+			boxes[i] = Box{
+				X:      centers[i].X - sizes[i].X/2,
+				Y:      centers[i].Y - sizes[i].Y/2,
+				Width:  sizes[i].X,
+				Height: sizes[i].Y,
+			}
+
+			offset += across(i) + m.gap
+		}
+
+		position += thickness[r] + gap
+	}
+
+	extent := position - gap + m.margin
+	breadth := longest + 2*m.margin
+	if diagram.Direction == types.LeftToRight {
+		layout.Width, layout.Height = extent, breadth
+	} else {
+		layout.Width, layout.Height = breadth, extent
+	}
+
+	for i, node := range diagram.Nodes {
+		layout.Boxes = append(layout.Boxes, boxes[i])
+		layout.Texts = append(layout.Texts, Text{Text: node.Label, At: centers[i]})
+	}
+
+	// Edges which skip a layer or point back up the chart are routed
+	// around the side in lanes of their own, so they don't cut through
+	// the nodes in between
+	outside := breadth
+	if diagram.Direction == types.TopToBottom {
+		// Labels sit beside edges, so the lanes have to clear them too
+		for _, edge := range diagram.Edges {
+			outside = math.Max(outside, breadth+css.TextWidth(edge.Label, fontSize)+m.margin)
+		}
+	}
+
+	// Flowing right, labels sit below their lanes, so the lanes are
+	// spaced out far enough for a label between each of them
+	spacing := m.gap / 2
+	if diagram.Direction == types.LeftToRight && gap > m.gap {
+		spacing += m.labelSpace
+	}
+
+	lanes := 0
+	lane := func() float64 {
+		lanes++
+		return outside + float64(lanes-1)*spacing
+	}
+
+	for _, edge := range diagram.Edges {
+		from, to := index[edge.From], index[edge.To]
+		routed := from != to && ranks[to]-ranks[from] != 1 && ranks[to] != ranks[from]
+
+		var points []Point
+		if routed && diagram.Direction == types.LeftToRight {
+			y := lane()
+			bottom := func(i int) Point {
+				return Point{X: centers[i].X, Y: boxes[i].Y + boxes[i].Height}
+			}
+			points = []Point{bottom(from), {X: centers[from].X, Y: y}, {X: centers[to].X, Y: y}, bottom(to)}
+			layout.Height = math.Max(layout.Height, y+m.margin)
+		} else if routed {
+			x := lane()
+			side := func(i int) Point {
+				return Point{X: boxes[i].X + boxes[i].Width, Y: centers[i].Y}
+			}
+			points = []Point{side(from), {X: x, Y: centers[from].Y}, {X: x, Y: centers[to].Y}, side(to)}
+			layout.Width = math.Max(layout.Width, x+m.margin)
+		} else if from == to {
+			// Loop out of the side of the box and back in again
+			box := boxes[from]
+			right := box.X + box.Width
+			points = []Point{
+				{X: right, Y: box.Y + box.Height/4},
+				{X: right + m.gap/2, Y: box.Y + box.Height/4},
+				{X: right + m.gap/2, Y: box.Y + box.Height*3/4},
+				{X: right, Y: box.Y + box.Height*3/4},
+			}
+			layout.Width = math.Max(layout.Width, right+m.gap/2+m.margin)
+		} else {
+			points = []Point{
+				clip(boxes[from], centers[from], centers[to]),
+				clip(boxes[to], centers[to], centers[from]),
+			}
+		}
+
+		layout.Connectors = append(layout.Connectors, Connector{Points: points, Dashed: edge.Dashed, Arrow: true})
+
+		if edge.Label != "" {
+			first, last := points[0], points[len(points)-1]
+			mid := Point{X: (first.X + last.X) / 2, Y: (first.Y + last.Y) / 2}
+			if routed {
+				mid = Point{X: (points[1].X + points[2].X) / 2, Y: (points[1].Y + points[2].Y) / 2}
+			}
+
+			if from == to {
+				mid.X = points[1].X + css.TextWidth(edge.Label, fontSize)/2 + m.margin
+				layout.Width = math.Max(layout.Width, mid.X+css.TextWidth(edge.Label, fontSize)/2+m.margin)
+			} else if diagram.Direction == types.LeftToRight && routed {
+				mid.Y += fontSize
+				layout.Height = math.Max(layout.Height, mid.Y+fontSize/2+m.margin)
+			} else if diagram.Direction == types.LeftToRight {
+				mid.Y -= fontSize
+			} else {
+				mid.X += css.TextWidth(edge.Label, fontSize)/2 + m.margin
+			}
+
+			layout.Texts = append(layout.Texts, Text{Text: edge.Label, At: mid})
+		}
+	}
+
+	// Labels beside edges may poke out past the widest layer
+	for _, text := range layout.Texts {
+		layout.Width = math.Max(layout.Width, text.At.X+css.TextWidth(text.Text, fontSize)/2+m.margin)
+	}
+
+	return layout
+}
+
+// Assign each node a layer one past the furthest node pointing at it.
+// Edges which would form a cycle are ignored, so that loops still
+// flow in the direction they were first written
+func rankNodes(diagram types.Diagram, index map[string]int) []int {
+	successors := make([][]int, len(diagram.Nodes))
+	for _, edge := range diagram.Edges {
+		from, to := index[edge.From], index[edge.To]
+		if from != to {
+			successors[from] = append(successors[from], to)
+		}
+	}
+
+	// Find the edges which point back at a node still being visited
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(diagram.Nodes))
+	forward := make([][]int, len(diagram.Nodes))
+
+	var visit func(int)
+	visit = func(i int) {
+		state[i] = visiting
+		for _, next := range successors[i] {
+			if state[next] == visiting {
+				continue
+			}
+
+			forward[i] = append(forward[i], next)
+			if state[next] == unvisited {
+				visit(next)
+			}
+		}
+		state[i] = visited
+	}
+
+	for i := range diagram.Nodes {
+		if state[i] == unvisited {
+			visit(i)
+		}
+	}
+
+	// Walk the remaining edges in topological order, pushing
+	// each node past every node which points at it
+	incoming := make([]int, len(diagram.Nodes))
+	for _, nexts := range forward {
+		for _, next := range nexts {
+			incoming[next]++
+		}
+	}
+
+	queue := make([]int, 0, len(diagram.Nodes))
+	for i, count := range incoming {
+		if count == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	ranks := make([]int, len(diagram.Nodes))
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+
+		for _, next := range forward[i] {
+			if ranks[i]+1 > ranks[next] {
+				ranks[next] = ranks[i] + 1
+			}
+
+			incoming[next]--
+			if incoming[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return ranks
+}
+
+// Sort each layer by the average position of its neighbours in the layer
+// before it. A few passes are enough to untangle most small diagrams
+func orderLayers(diagram types.Diagram, index map[string]int, layers [][]int) {
+	neighbours := make([][]int, len(diagram.Nodes))
+	for _, edge := range diagram.Edges {
+		from, to := index[edge.From], index[edge.To]
+		neighbours[from] = append(neighbours[from], to)
+		neighbours[to] = append(neighbours[to], from)
+	}
+
+	positions := make([]float64, len(diagram.Nodes))
+	for _, layer := range layers {
+		for j, i := range layer {
+			positions[i] = float64(j)
+		}
+	}
+
+	for pass := 0; pass < 4; pass++ {
+		for r := 1; r < len(layers); r++ {
+			previous := make(map[int]bool, len(layers[r-1]))
+			for _, i := range layers[r-1] {
+				previous[i] = true
+			}
+
+			barycenters := make(map[int]float64, len(layers[r]))
+			for _, i := range layers[r] {
+				sum, count := 0.0, 0
+				for _, neighbour := range neighbours[i] {
+					if previous[neighbour] {
+						sum += positions[neighbour]
+						count++
+					}
+				}
+
+				if count > 0 {
+					barycenters[i] = sum / float64(count)
+				} else {
+					barycenters[i] = positions[i]
+				}
+			}
+
+			layer := layers[r]
+			sort.SliceStable(layer, func(a, b int) bool {
+				return barycenters[layer[a]] < barycenters[layer[b]]
+			})
+
+			for j, i := range layer {
+				positions[i] = float64(j)
+			}
+		}
+	}
+}
+
+// Find where the line from a box's center towards a point leaves the box
+func clip(box Box, center Point, towards Point) Point {
+	dx, dy := towards.X-center.X, towards.Y-center.Y
+	if dx == 0 && dy == 0 {
+		return center
+	}
+
+	scale := math.Inf(1)
+	if dx != 0 {
+		scale = math.Min(scale, box.Width/2/math.Abs(dx))
+	}
+	if dy != 0 {
+		scale = math.Min(scale, box.Height/2/math.Abs(dy))
+	}
+
+	return Point{X: center.X + dx*scale, Y: center.Y + dy*scale}
+}
+
+// Sequence diagrams give each node a column, with a lifeline running down
+// from its box. Each edge is a message, drawn below the one before it
+func arrangeSequence(diagram types.Diagram, fontSize float64) Layout {
+	m := newMetrics(fontSize)
+
+	index := make(map[string]int, len(diagram.Nodes))
+	widths := make([]float64, len(diagram.Nodes))
+	for i, node := range diagram.Nodes {
+		index[node.ID] = i
+		widths[i] = m.boxWidth(node.Label)
+	}
+
+	// Columns are spaced evenly, far enough apart for the
+	// widest box and for every message label to fit
+	spacing := 0.0
+	for _, width := range widths {
+		spacing = math.Max(spacing, width+m.gap)
+	}
+
+	for _, edge := range diagram.Edges {
+		from, to := index[edge.From], index[edge.To]
+		span := math.Abs(float64(to - from))
+		if span == 0 {
+			span = 0.5
+		}
+
+		spacing = math.Max(spacing, (css.TextWidth(edge.Label, fontSize)+m.gap)/span)
+	}
+
+	step := m.boxHeight + m.labelSpace/2
+	bottom := m.margin + m.boxHeight + step*float64(len(diagram.Edges)+1)
+
+	layout := Layout{
+		Width:  spacing*float64(len(diagram.Nodes)) + 2*m.margin,
+		Height: bottom + m.margin,
+	}
+
+	columns := make([]float64, len(diagram.Nodes))
+	for i, node := range diagram.Nodes {
+		columns[i] = m.margin + spacing*(float64(i)+0.5)
+		top := Point{X: columns[i], Y: m.margin + m.boxHeight/2}
+
+		layout.Boxes = append(layout.Boxes, Box{
+			X:      columns[i] - widths[i]/2,
+			Y:      m.margin,
+			Width:  widths[i],
+			Height: m.boxHeight,
+		})
+		layout.Texts = append(layout.Texts, Text{Text: node.Label, At: top})
+		layout.Connectors = append(layout.Connectors, Connector{
+			Points: []Point{{X: columns[i], Y: m.margin + m.boxHeight}, {X: columns[i], Y: bottom}},
+			Dashed: true,
+		})
+	}
+
+	for k, edge := range diagram.Edges {
+		y := m.margin + m.boxHeight + step*float64(k+1)
+		from, to := columns[index[edge.From]], columns[index[edge.To]]
+
+		var points []Point
+		if from == to {
+			// Messages to oneself loop out to the right and back
+			loop := spacing / 4
+			points = []Point{{X: from, Y: y - step/4}, {X: from + loop, Y: y - step/4}, {X: from + loop, Y: y + step/4}, {X: from, Y: y + step/4}}
+		} else {
+			points = []Point{{X: from, Y: y}, {X: to, Y: y}}
+		}
+
+		if edge.Label != "" {
+			label := Point{X: (from + to) / 2, Y: y - fontSize*0.8}
+			if from == to {
+				label = Point{X: points[1].X + css.TextWidth(edge.Label, fontSize)/2 + m.margin, Y: y}
+			}
+
+			layout.Texts = append(layout.Texts, Text{Text: edge.Label, At: label})
+		}
+
+		layout.Connectors = append(layout.Connectors, Connector{Points: points, Dashed: edge.Dashed, Arrow: true})
+	}
+
+	for _, text := range layout.Texts {
+		layout.Width = math.Max(layout.Width, text.At.X+css.TextWidth(text.Text, fontSize)/2+m.margin)
+	}
+
+	return layout
+}
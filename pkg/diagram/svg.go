@@ -0,0 +1,72 @@
+package diagram
+
+import (
+	"fmt"
+	"html"
+	"image/color"
+	"strings"
+
+	"github.com/mbStavola/slydes/pkg/css"
+)
+
+// SVG draws the layout in the given color, with text of the given size.
+// An empty font family leaves the text in whatever font surrounds the SVG.
+// The id must be unique within the page the SVG is placed in
+func (l Layout) SVG(id string, fontSize float64, fontFamily string, c color.Color) string {
+	marker := id + "-arrow"
+	ink := css.RGBA(c)
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	fill := css.RGBA(color.NRGBA{R: nrgba.R, G: nrgba.G, B: nrgba.B, A: nrgba.A / 10})
+
+	svg := strings.Builder{}
+	fmt.Fprintf(
+		&svg,
+		`<svg class="diagram" id="%s" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %s %s" width="%s" height="%s">`,
+		html.EscapeString(id), css.Number(l.Width), css.Number(l.Height), css.Number(l.Width), css.Number(l.Height),
+	)
+
+	// Every arrow shares the one head, drawn in the same color as the lines
+	fmt.Fprintf(
+		&svg,
+		`<defs><marker id="%s" viewBox="0 0 10 10" refX="9" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="%s"/></marker></defs>`,
+		html.EscapeString(marker), ink,
+	)
+
+	for _, box := range l.Boxes {
+		fmt.Fprintf(
+			&svg,
+			`<rect x="%s" y="%s" width="%s" height="%s" rx="4" fill="%s" stroke="%s" stroke-width="1.5"/>`,
+			css.Number(box.X), css.Number(box.Y), css.Number(box.Width), css.Number(box.Height), fill, ink,
+		)
+	}
+
+	for _, connector := range l.Connectors {
+		points := make([]string, len(connector.Points))
+		for i, point := range connector.Points {
+			points[i] = css.Number(point.X) + "," + css.Number(point.Y)
+		}
+
+		fmt.Fprintf(&svg, `<polyline points="%s" fill="none" stroke="%s" stroke-width="1.5"`, strings.Join(points, " "), ink)
+		if connector.Dashed {
+			svg.WriteString(` stroke-dasharray="6 4"`)
+		}
+		if connector.Arrow {
+			fmt.Fprintf(&svg, ` marker-end="url(#%s)"`, html.EscapeString(marker))
+		}
+		svg.WriteString("/>")
+	}
+
+	fmt.Fprintf(&svg, `<g fill="%s" font-size="%s" text-anchor="middle" dominant-baseline="central"`, ink, css.Number(fontSize))
+	if fontFamily != "" {
+		fmt.Fprintf(&svg, ` font-family="%s"`, html.EscapeString(fontFamily))
+	}
+	svg.WriteString(">")
+
+	for _, text := range l.Texts {
+		fmt.Fprintf(&svg, `<text x="%s" y="%s">%s</text>`, css.Number(text.At.X), css.Number(text.At.Y), html.EscapeString(text.Text))
+	}
+
+	svg.WriteString("</g></svg>")
+
+	return svg.String()
+}
@@ -0,0 +1,429 @@
+// Package highlight splits source code into colored spans for display
+package highlight
+
+import (
+	"image/color"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Kind int
+
+const (
+	Plain Kind = iota
+	Keyword
+	Builtin
+	Function
+	String
+	Number
+	Comment
+)
+
+func (k Kind) String() string {
+	return []string{
+		"Plain",
+		"Keyword",
+		"Builtin",
+		"Function",
+		"String",
+		"Number",
+		"Comment",
+	}[k]
+}
+
+// A Span is a run of text on a single line which is all one kind
+type Span struct {
+	Kind Kind
+	Text string
+}
+
+// A Line holds the spans of one line of source, without its newline
+type Line []Span
+
+// Palette maps each kind of span to the color it is drawn in
+type Palette struct {
+	Background color.Color
+	// Drawn behind lines which should stand out
+	Highlight  color.Color
+	LineNumber color.Color
+	Colors     map[Kind]color.Color
+}
+
+func (p Palette) Color(kind Kind) color.Color {
+	return p.Colors[kind]
+}
+
+var LightPalette = Palette{
+	Background: color.RGBA{R: 246, G: 248, B: 250, A: 255},
+	Highlight:  color.RGBA{R: 255, G: 248, B: 197, A: 255},
+	LineNumber: color.RGBA{R: 140, G: 149, B: 159, A: 255},
+	Colors: map[Kind]color.Color{
+		Plain:    color.RGBA{R: 36, G: 41, B: 47, A: 255},
+		Keyword:  color.RGBA{R: 207, G: 34, B: 46, A: 255},
+		Builtin:  color.RGBA{R: 5, G: 80, B: 174, A: 255},
+		Function: color.RGBA{R: 130, G: 80, B: 223, A: 255},
+		String:   color.RGBA{R: 10, G: 48, B: 105, A: 255},
+		Number:   color.RGBA{R: 5, G: 80, B: 174, A: 255},
+		Comment:  color.RGBA{R: 110, G: 119, B: 129, A: 255},
+	},
+}
+
+var DarkPalette = Palette{
+	Background: color.RGBA{R: 13, G: 17, B: 23, A: 255},
+	Highlight:  color.RGBA{R: 56, G: 49, B: 20, A: 255},
+	LineNumber: color.RGBA{R: 110, G: 118, B: 129, A: 255},
+	Colors: map[Kind]color.Color{
+		Plain:    color.RGBA{R: 230, G: 237, B: 243, A: 255},
+		Keyword:  color.RGBA{R: 255, G: 123, B: 114, A: 255},
+		Builtin:  color.RGBA{R: 121, G: 192, B: 255, A: 255},
+		Function: color.RGBA{R: 210, G: 168, B: 255, A: 255},
+		String:   color.RGBA{R: 165, G: 214, B: 255, A: 255},
+		Number:   color.RGBA{R: 121, G: 192, B: 255, A: 255},
+		Comment:  color.RGBA{R: 139, G: 148, B: 158, A: 255},
+	},
+}
+
+// The rules used to pick apart a language's source
+type language struct {
+	keywords     []string
+	builtins     []string
+	lineComment  string
+	blockComment [2]string
+	// Characters which open and close a string literal
+	quotes string
+	// Quotes whose strings may span several lines
+	multilineQuotes string
+}
+
+var languages = map[string]language{
+	"go": {
+		keywords: []string{
+			"break", "case", "chan", "const", "continue", "default", "defer", "else",
+			"fallthrough", "for", "func", "go", "goto", "if", "import", "interface",
+			"map", "package", "range", "return", "select", "struct", "switch", "type", "var",
+		},
+		builtins: []string{
+			"append", "bool", "byte", "cap", "close", "complex", "complex64", "complex128",
+			"copy", "delete", "error", "false", "float32", "float64", "imag", "int", "int8",
+			"int16", "int32", "int64", "iota", "len", "make", "new", "nil", "panic", "print",
+			"println", "real", "recover", "rune", "string", "true", "uint", "uint8", "uint16",
+			"uint32", "uint64", "uintptr",
+		},
+		lineComment:     "//",
+		blockComment:    [2]string{"/*", "*/"},
+		quotes:          "\"'`",
+		multilineQuotes: "`",
+	},
+	"sly": {
+		keywords:    []string{"let", "mut", "macro", "master", "slide", "block", "code", "table", "diagram", "chart", "rect", "ellipse", "line", "arrow", "style", "self"},
+		builtins:    []string{"true", "false"},
+		lineComment: "#",
+		quotes:      "\"",
+	},
+	"javascript": {
+		keywords: []string{
+			"async", "await", "break", "case", "catch", "class", "const", "continue",
+			"default", "delete", "do", "else", "export", "extends", "finally", "for",
+			"function", "if", "import", "in", "instanceof", "let", "new", "of", "return",
+			"static", "super", "switch", "this", "throw", "try", "typeof", "var", "void",
+			"while", "yield",
+		},
+		builtins:        []string{"false", "null", "true", "undefined", "NaN", "Infinity"},
+		lineComment:     "//",
+		blockComment:    [2]string{"/*", "*/"},
+		quotes:          "\"'`",
+		multilineQuotes: "`",
+	},
+	"python": {
+		keywords: []string{
+			"and", "as", "assert", "async", "await", "break", "class", "continue", "def",
+			"del", "elif", "else", "except", "finally", "for", "from", "global", "if",
+			"import", "in", "is", "lambda", "nonlocal", "not", "or", "pass", "raise",
+			"return", "try", "while", "with", "yield",
+		},
+		builtins: []string{
+			"False", "None", "True", "bool", "dict", "float", "int", "len", "list",
+			"print", "range", "self", "set", "str", "tuple",
+		},
+		lineComment: "#",
+		quotes:      "\"'",
+	},
+	"rust": {
+		keywords: []string{
+			"as", "async", "await", "break", "const", "continue", "crate", "dyn", "else",
+			"enum", "extern", "fn", "for", "if", "impl", "in", "let", "loop", "match", "mod",
+			"move", "mut", "pub", "ref", "return", "self", "Self", "static", "struct",
+			"super", "trait", "type", "unsafe", "use", "where", "while",
+		},
+		builtins: []string{
+			"bool", "char", "f32", "f64", "false", "i8", "i16", "i32", "i64", "i128",
+			"isize", "Option", "Result", "Some", "None", "Ok", "Err", "str", "String",
+			"true", "u8", "u16", "u32", "u64", "u128", "usize", "Vec",
+		},
+		lineComment:     "//",
+		blockComment:    [2]string{"/*", "*/"},
+		quotes:          "\"",
+		multilineQuotes: "\"",
+	},
+	"c": {
+		keywords: []string{
+			"break", "case", "const", "continue", "default", "do", "else", "enum", "extern",
+			"for", "goto", "if", "inline", "register", "return", "sizeof", "static",
+			"struct", "switch", "typedef", "union", "volatile", "while",
+		},
+		builtins: []string{
+			"char", "double", "float", "int", "long", "short", "signed", "unsigned",
+			"void", "NULL", "bool", "true", "false", "size_t",
+		},
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+	},
+	"java": {
+		keywords: []string{
+			"abstract", "break", "case", "catch", "class", "continue", "default", "do",
+			"else", "enum", "extends", "final", "finally", "for", "if", "implements",
+			"import", "instanceof", "interface", "new", "package", "private", "protected",
+			"public", "return", "static", "super", "switch", "synchronized", "this",
+			"throw", "throws", "try", "var", "while",
+		},
+		builtins: []string{
+			"boolean", "byte", "char", "double", "false", "float", "int", "long", "null",
+			"short", "String", "true", "void",
+		},
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+	},
+	"shell": {
+		keywords: []string{
+			"case", "do", "done", "elif", "else", "esac", "export", "fi", "for",
+			"function", "if", "in", "local", "return", "then", "until", "while",
+		},
+		builtins:    []string{"cd", "echo", "exit", "printf", "read", "set", "source", "test"},
+		lineComment: "#",
+		quotes:      "\"'",
+	},
+	"json": {
+		builtins: []string{"false", "null", "true"},
+		quotes:   "\"",
+	},
+}
+
+var aliases = map[string]string{
+	"golang": "go",
+	"js":     "javascript",
+	"py":     "python",
+	"rs":     "rust",
+	"sh":     "shell",
+	"bash":   "shell",
+}
+
+// Languages lists the names of every language which can be highlighted
+func Languages() []string {
+	names := make([]string, 0, len(languages)+len(aliases))
+	for name := range languages {
+		names = append(names, name)
+	}
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Supported reports whether source in the named language can be highlighted
+func Supported(name string) bool {
+	_, ok := lookup(name)
+	return ok
+}
+
+func lookup(name string) (language, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+
+	lang, ok := languages[name]
+	return lang, ok
+}
+
+// Highlight splits source into lines of spans. Source in a language
+// which is not supported is returned as plain text
+func Highlight(name string, source string) []Line {
+	lang, ok := lookup(name)
+	if !ok {
+		return plainLines(source)
+	}
+
+	h := highlighter{
+		lang:     lang,
+		source:   source,
+		keywords: toSet(lang.keywords),
+		builtins: toSet(lang.builtins),
+		lines:    []Line{{}},
+	}
+	h.run()
+
+	return h.lines
+}
+
+func plainLines(source string) []Line {
+	texts := strings.Split(source, "\n")
+	lines := make([]Line, len(texts))
+	for i, text := range texts {
+		if text != "" {
+			lines[i] = Line{{Kind: Plain, Text: text}}
+		}
+	}
+
+	return lines
+}
+
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+
+	return set
+}
+
+type highlighter struct {
+	lang     language
+	source   string
+	keywords map[string]bool
+	builtins map[string]bool
+
+	current int
+	lines   []Line
+}
+
+func (h *highlighter) run() {
+	for h.current < len(h.source) {
+		rest := h.source[h.current:]
+		char, size := utf8.DecodeRuneInString(rest)
+
+		switch {
+		case h.lang.lineComment != "" && strings.HasPrefix(rest, h.lang.lineComment):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			h.emit(Comment, end)
+		case h.lang.blockComment[0] != "" && strings.HasPrefix(rest, h.lang.blockComment[0]):
+			open := len(h.lang.blockComment[0])
+			end := strings.Index(rest[open:], h.lang.blockComment[1])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += open + len(h.lang.blockComment[1])
+			}
+			h.emit(Comment, end)
+		case strings.ContainsRune(h.lang.quotes, char):
+			h.emit(String, h.stringLength(rest, char, size))
+		case unicode.IsDigit(char):
+			h.emit(Number, h.numberLength(rest))
+		case char == '_' || unicode.IsLetter(char):
+			length := h.wordLength(rest)
+			word := rest[:length]
+
+			kind := Plain
+			if h.keywords[word] {
+				kind = Keyword
+			} else if h.builtins[word] {
+				kind = Builtin
+			} else if strings.HasPrefix(strings.TrimLeft(rest[length:], " \t"), "(") {
+				kind = Function
+			}
+			h.emit(kind, length)
+		default:
+			h.emit(Plain, size)
+		}
+	}
+}
+
+// The length of a string literal, including its quotes. Escapes are
+// skipped over, and strings which may not span lines stop at the newline
+func (h *highlighter) stringLength(rest string, quote rune, size int) int {
+	multiline := strings.ContainsRune(h.lang.multilineQuotes, quote)
+	raw := quote == '`'
+
+	i := size
+	for i < len(rest) {
+		char, charSize := utf8.DecodeRuneInString(rest[i:])
+		switch {
+		case char == '\\' && !raw:
+			i += charSize
+			if i < len(rest) {
+				_, escapedSize := utf8.DecodeRuneInString(rest[i:])
+				i += escapedSize
+			}
+			continue
+		case char == quote:
+			return i + charSize
+		case char == '\n' && !multiline:
+			return i
+		}
+
+		i += charSize
+	}
+
+	return i
+}
+
+func (h *highlighter) numberLength(rest string) int {
+	i := 0
+	for i < len(rest) {
+		char, size := utf8.DecodeRuneInString(rest[i:])
+		if !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '.' && char != '_' {
+			break
+		}
+
+		i += size
+	}
+
+	return i
+}
+
+func (h *highlighter) wordLength(rest string) int {
+	i := 0
+	for i < len(rest) {
+		char, size := utf8.DecodeRuneInString(rest[i:])
+		if char != '_' && !unicode.IsLetter(char) && !unicode.IsDigit(char) {
+			break
+		}
+
+		i += size
+	}
+
+	return i
+}
+
+// Append the next n bytes of source as spans of the given kind,
+// starting a new line at every newline and merging adjacent spans
+func (h *highlighter) emit(kind Kind, n int) {
+	text := h.source[h.current : h.current+n]
+	h.current += n
+
+	for i, part := range strings.Split(text, "\n") {
+		if i > 0 {
+			h.lines = append(h.lines, Line{})
+		}
+
+		if part == "" {
+			continue
+		}
+
+		line := &h.lines[len(h.lines)-1]
+		if last := len(*line) - 1; last >= 0 && (*line)[last].Kind == kind {
+			(*line)[last].Text += part
+		} else {
+			*line = append(*line, Span{Kind: kind, Text: part})
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package highlight
+
+import "testing"
+
+func TestHighlightGo(t *testing.T) {
+	source := "func main() {\n\t// Say hi\n\tfmt.Println(\"hi\", 42)\n}"
+
+	lines := Highlight("go", source)
+	if len(lines) != 4 {
+		t.Errorf("Expected exactly four lines-- got %d", len(lines))
+		return
+	}
+
+	expected := []Span{
+		{Kind: Keyword, Text: "func"},
+		{Kind: Plain, Text: " "},
+		{Kind: Function, Text: "main"},
+		{Kind: Plain, Text: "() {"},
+	}
+	for i, span := range expected {
+		if lines[0][i] != span {
+			t.Errorf("Expected %v in position %d-- got %v", span, i+1, lines[0][i])
+			return
+		}
+	}
+
+	if comment := lines[1][1]; comment.Kind != Comment || comment.Text != "// Say hi" {
+		t.Errorf("Expected a comment-- got %v", comment)
+		return
+	}
+
+	kinds := make([]Kind, 0)
+	for _, span := range lines[2] {
+		kinds = append(kinds, span.Kind)
+	}
+
+	if kinds[1] != Function || kinds[3] != String || kinds[5] != Number {
+		t.Errorf("Expected a function call with a string and number-- got %v", lines[2])
+	}
+}
+
+func TestHighlightPreservesText(t *testing.T) {
+	source := "x := `raw\n  string`\n/* multi\n   line */\n\n\ty := 'a'"
+
+	lines := Highlight("go", source)
+
+	text := ""
+	for i, line := range lines {
+		if i > 0 {
+			text += "\n"
+		}
+
+		for _, span := range line {
+			text += span.Text
+		}
+	}
+
+	if text != source {
+		t.Errorf("Expected %q-- got %q", source, text)
+		return
+	}
+
+	if lines[1][0].Kind != String || lines[3][0].Kind != Comment {
+		t.Errorf("Expected multiline strings and comments to continue onto the next line-- got %v and %v", lines[1], lines[3])
+	}
+}
+
+func TestUnsupportedLanguage(t *testing.T) {
+	if Supported("cobol") {
+		t.Errorf("Expected COBOL to be unsupported")
+		return
+	}
+
+	lines := Highlight("cobol", "DISPLAY 'HI'.")
+	if len(lines) != 1 || lines[0][0].Kind != Plain {
+		t.Errorf("Expected a single plain line-- got %v", lines)
+	}
+}
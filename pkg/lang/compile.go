@@ -1,10 +1,20 @@
 package lang
 
 import (
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"image/color"
-
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mbStavola/slydes/pkg/diagram"
+	"github.com/mbStavola/slydes/pkg/highlight"
+	"github.com/mbStavola/slydes/pkg/tex"
 	"github.com/mbStavola/slydes/pkg/types"
 )
 
@@ -16,6 +26,7 @@ const (
 	FileScope
 	SlideScope
 	BlockScope
+	ShapeScope
 )
 
 func (s ScopeType) String() string {
@@ -25,6 +36,7 @@ func (s ScopeType) String() string {
 		"FileScope",
 		"SlideScope",
 		"BlockScope",
+		"ShapeScope",
 	}[s]
 }
 
@@ -37,7 +49,9 @@ type scope struct {
 	Type      ScopeType
 	parent    *scope
 	slides    map[string]types.Slide
+	masters   map[string]master
 	blocks    map[string]types.Block
+	shapes    map[string]types.Shape
 	variables map[string]variableValue
 	macros    map[string][]Statement
 }
@@ -46,7 +60,9 @@ func newTopLevelScope() *scope {
 	scope := new(scope)
 	scope.Type = FileScope
 	scope.slides = make(map[string]types.Slide)
+	scope.masters = make(map[string]master)
 	scope.blocks = make(map[string]types.Block)
+	scope.shapes = make(map[string]types.Shape)
 	scope.variables = make(map[string]variableValue)
 	scope.macros = make(map[string][]Statement)
 
@@ -93,6 +109,15 @@ func (s *scope) getVariable(token Token, name string) (interface{}, error) {
 	return variable.value, nil
 }
 
+func (s *scope) getMaster(name string) (master, bool) {
+	master, ok := s.masters[name]
+	if !ok && s.parent != nil {
+		return s.parent.getMaster(name)
+	}
+
+	return master, ok
+}
+
 func (s *scope) declareMacro(token Token, name string, statements []Statement) error {
 	if _, ok := s.macros[name]; !ok {
 		s.macros[name] = statements
@@ -113,11 +138,73 @@ func (s *scope) getMacro(token Token, name string) ([]Statement, error) {
 	return statements, nil
 }
 
+// A master is a slide which is never shown, but instead lays out
+// the slides inheriting from it. Each of its blocks is a placeholder
+// which an inheriting slide fills by declaring a block of the same name
+type master struct {
+	slide        types.Slide
+	decorations  decorationOverrides
+	placeholders []string
+}
+
+func (m master) placeholder(name string) (types.Block, bool) {
+	for i, placeholder := range m.placeholders {
+		if placeholder == name {
+			return m.slide.Blocks[i], true
+		}
+	}
+
+	return types.Block{}, false
+}
+
+// Order the blocks of an inheriting slide to match the master, followed by
+// any blocks which do not fill a placeholder. Unfilled placeholders are only
+// kept if they have text of their own (ex: a copyright notice), unless
+// keepEmpty is set because the slide is itself a master
+func (m master) arrange(blocks []types.Block, names []string, keepEmpty bool) ([]types.Block, []string) {
+	filled := make(map[string]int)
+	for i, name := range names {
+		filled[name] = i
+	}
+
+	arranged := make([]types.Block, 0, len(blocks)+len(m.placeholders))
+	arrangedNames := make([]string, 0, len(blocks)+len(m.placeholders))
+	for i, name := range m.placeholders {
+		if j, ok := filled[name]; ok {
+			arranged = append(arranged, blocks[j])
+		} else if keepEmpty || m.slide.Blocks[i].Words != "" {
+			arranged = append(arranged, m.slide.Blocks[i])
+		} else {
+			continue
+		}
+
+		arrangedNames = append(arrangedNames, name)
+	}
+
+	for i, name := range names {
+		if _, ok := m.placeholder(name); !ok {
+			arranged = append(arranged, blocks[i])
+			arrangedNames = append(arrangedNames, name)
+		}
+	}
+
+	return arranged, arrangedNames
+}
+
 type Compiler interface {
 	Compile(statements []Statement) (types.Show, error)
 }
 
-type DefaultCompiler struct{}
+type DefaultCompiler struct {
+	// The theme to compile with, taking precedence over any
+	// @theme directive. Either the name of a built-in theme
+	// or the path to a .sly file
+	Theme string
+
+	// The directory relative paths are resolved against,
+	// usually the one containing the file being compiled
+	BaseDir string
+}
 
 func NewDefaultCompiler() DefaultCompiler {
 	return DefaultCompiler{}
@@ -125,28 +212,104 @@ func NewDefaultCompiler() DefaultCompiler {
 
 func (comp DefaultCompiler) Compile(statements []Statement) (types.Show, error) {
 	state := newCompilationState()
+	state.baseDir = comp.BaseDir
 	errBundle := newErrorInfoBundle()
 
-	for _, statement := range statements {
-		if err := state.processStatement(statement); err != nil && errors.As(err, &ErrorInfo{}) {
-			errBundle.Add(err.(ErrorInfo))
-		} else if err != nil {
+	themeName := comp.Theme
+	if themeName == "" {
+		themeName = themeDirective(statements)
+	}
+
+	// The theme is compiled in a scope of its own, so
+	// the file can redeclare anything the theme defines
+	if themeName != "" {
+		theme, err := loadTheme(themeName, comp.BaseDir)
+		if err != nil {
 			return types.Show{}, err
 		}
+
+		state.compilingTheme = true
+		if err := state.compileStatements(theme.statements, &errBundle); err != nil {
+			return types.Show{}, err
+		}
+		state.compilingTheme = false
+
+		state.openScope(FileScope)
+	}
+
+	if err := state.compileStatements(statements, &errBundle); err != nil {
+		return types.Show{}, err
 	}
 
 	if errBundle.HasErrors() {
 		return types.Show{}, errBundle
 	}
 
-	return state.finalizeCompilation(), nil
+	show, err := state.finalizeCompilation()
+	if err != nil {
+		errBundle.Add(err.(ErrorInfo))
+		return types.Show{}, errBundle
+	}
+
+	return show, nil
 }
 
 type compilationState struct {
 	show  types.Show
 	slide *types.Slide
 	block *types.Block
+	shape *types.Shape
 	scope *scope
+
+	// The master of the slide currently being compiled, if it has one,
+	// and the names of the slide's blocks in the order they were declared
+	master     *master
+	blockNames []string
+
+	// Styles declared at the top level, and the attribute statements
+	// each one expands to once its parents have been resolved
+	styles           map[string]Statement
+	linearizedStyles map[string][]Statement
+	// Styles in the middle of being applied, innermost last, so that
+	// styles which apply themselves through self.apply are caught
+	applyingStyles []string
+	// Styles which came from the theme, and so may be redeclared
+	themeStyles    map[string]bool
+	compilingTheme bool
+
+	// The directory relative paths are resolved against
+	baseDir string
+
+	// Show dimensions as declared by directives, resolved
+	// into the final dimensions once compilation is done
+	aspectRatio      types.Dimensions
+	aspectRatioToken Token
+	width            uint
+	widthToken       Token
+	height           uint
+	heightToken      Token
+
+	// Show-wide decorations and the overrides made by each slide,
+	// which are resolved onto the slides once they are all known
+	decorations       decorationSettings
+	slideDecorations  *decorationOverrides
+	decorationsByIdx  []decorationOverrides
+	decorationsByName map[string]decorationOverrides
+}
+
+type decorationSettings struct {
+	header             string
+	footer             string
+	slideNumbers       bool
+	decorateTitleSlide bool
+}
+
+// Overrides are nil unless the slide (or a slide it
+// inherits from) sets the corresponding attribute
+type decorationOverrides struct {
+	header      *string
+	footer      *string
+	slideNumber *bool
 }
 
 func newCompilationState() compilationState {
@@ -157,7 +320,45 @@ func newCompilationState() compilationState {
 		slide: nil,
 		block: nil,
 		scope: newTopLevelScope(),
+
+		decorations: decorationSettings{
+			decorateTitleSlide: true,
+		},
+		decorationsByIdx:  make([]decorationOverrides, 0, 32),
+		decorationsByName: make(map[string]decorationOverrides),
+
+		styles:           make(map[string]Statement),
+		linearizedStyles: make(map[string][]Statement),
+		themeStyles:      make(map[string]bool),
+	}
+}
+
+// Compile a sequence of top level statements, collecting any errors
+// into the bundle. Other errors stop compilation and are returned
+func (cs *compilationState) compileStatements(statements []Statement, errBundle *ErrorInfoBundle) error {
+	// Styles may be used before they are declared, so
+	// gather them up before compiling anything else
+	for _, statement := range statements {
+		if statement.Type != StyleDecl {
+			continue
+		}
+
+		if err := cs.declareStyle(statement); err != nil && errors.As(err, &ErrorInfo{}) {
+			errBundle.Add(err.(ErrorInfo))
+		} else if err != nil {
+			return err
+		}
+	}
+
+	for _, statement := range statements {
+		if err := cs.processStatement(statement); err != nil && errors.As(err, &ErrorInfo{}) {
+			errBundle.Add(err.(ErrorInfo))
+		} else if err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
 func (cs *compilationState) openScope(ty ScopeType) {
@@ -165,7 +366,9 @@ func (cs *compilationState) openScope(ty ScopeType) {
 	scope.Type = ty
 	scope.parent = cs.scope
 	scope.slides = make(map[string]types.Slide)
+	scope.masters = make(map[string]master)
 	scope.blocks = make(map[string]types.Block)
+	scope.shapes = make(map[string]types.Shape)
 	scope.variables = make(map[string]variableValue)
 	scope.macros = make(map[string][]Statement)
 
@@ -185,29 +388,32 @@ func (cs *compilationState) processStatement(statement Statement) error {
 			return tokenErrorInfo(statement.token, compilation, "A slide may only be defined at the top level")
 		}
 
-		slide := types.NewSlide()
-		cs.slide = &slide
+		slide, overrides, err := cs.compileSlide(statement.token, decl.name, decl.parent, decl.statements, false)
+		if err != nil {
+			return err
+		}
 
-		// If the slide has a parent, copy the parent's attributes
-		if decl.parent != "" {
-			parent, ok := cs.scope.slides[decl.parent]
-			if !ok {
-				return tokenErrorInfo(statement.token, compilation, "Cannot inherit from an undefined slide")
-			}
+		cs.show.Slides = append(cs.show.Slides, slide)
+		cs.scope.slides[decl.name] = slide
+		cs.decorationsByIdx = append(cs.decorationsByIdx, overrides)
+		cs.decorationsByName[decl.name] = overrides
+	case MasterDecl:
+		decl := statement.data.(MasterDeclaration)
 
-			slide.Background = parent.Background
+		if cs.scope.Type != FileScope {
+			return tokenErrorInfo(statement.token, compilation, "A master may only be defined at the top level")
 		}
 
-		cs.openScope(SlideScope)
-		for _, statement := range decl.statements {
-			if err := cs.processStatement(statement); err != nil {
-				return err
-			}
+		slide, overrides, err := cs.compileSlide(statement.token, decl.name, decl.parent, decl.statements, true)
+		if err != nil {
+			return err
 		}
-		cs.closeScope()
 
-		cs.show.Slides = append(cs.show.Slides, slide)
-		cs.scope.slides[decl.name] = slide
+		cs.scope.masters[decl.name] = master{
+			slide:        slide,
+			decorations:  overrides,
+			placeholders: cs.blockNames,
+		}
 	case BlockDecl:
 		decl := statement.data.(BlockDeclaration)
 
@@ -218,14 +424,75 @@ func (cs *compilationState) processStatement(statement Statement) error {
 		block := types.NewBlock()
 		cs.block = &block
 
-		// If the block has a parent, copy the parent's attributes
-		if decl.parent != "" {
-			parent, ok := cs.scope.blocks[decl.parent]
-			if !ok {
-				return tokenErrorInfo(statement.token, compilation, "Cannot inherit from an undefined block")
+		// A block named after one of the master's placeholders starts
+		// off with the placeholder's style, position and text. Otherwise,
+		// the default style (usually from the theme) is the starting point
+		placeholder, isPlaceholder := types.Block{}, false
+		if cs.master != nil {
+			placeholder, isPlaceholder = cs.master.placeholder(decl.name)
+		}
+
+		if isPlaceholder {
+			block = placeholder
+		} else if _, ok := cs.styles[defaultName]; ok {
+			if err := cs.applyStyle(statement.token, defaultName); err != nil {
+				return err
+			}
+		}
+
+		if block.Table != nil {
+			table := *block.Table
+			block.Table = &table
+		} else if decl.kind == Table {
+			table := types.NewTable()
+			block.Table = &table
+		}
+
+		if block.Chart != nil {
+			chart := *block.Chart
+			block.Chart = &chart
+		} else if decl.kind == Chart {
+			chart := types.NewChart()
+			if colors, err := cs.scope.getVariable(statement.token, chartColorsName); err == nil {
+				if colors, ok := colors.(colorPalette); ok {
+					chart.Colors = colors
+				}
+			}
+
+			block.Chart = &chart
+		}
+
+		if block.Diagram != nil {
+			diagram := *block.Diagram
+			block.Diagram = &diagram
+		} else if decl.kind == Diagram {
+			block.Diagram = &types.Diagram{}
+		}
+
+		if block.Code != nil {
+			code := *block.Code
+			block.Code = &code
+		} else if decl.kind == Code {
+			block.Code = &types.Code{}
+
+			// Code is set in a monospaced font unless told otherwise
+			block.Style.Font = "Menlo"
+			block.Style.FontFallbacks = []string{"Consolas", "monospace"}
+		}
+
+		// Copy the attributes of each parent in turn, so
+		// later parents take precedence over earlier ones
+		for _, parentName := range decl.parents {
+			if parent, ok := cs.scope.blocks[parentName]; ok {
+				block.Style = parent.Style
+				continue
+			} else if _, ok := cs.styles[parentName]; !ok {
+				return tokenErrorInfo(statement.token, compilation, "Cannot inherit from an undefined block or style")
 			}
 
-			block.Style = parent.Style
+			if err := cs.applyStyle(statement.token, parentName); err != nil {
+				return err
+			}
 		}
 
 		cs.openScope(BlockScope)
@@ -238,26 +505,112 @@ func (cs *compilationState) processStatement(statement Statement) error {
 
 		cs.slide.Blocks = append(cs.slide.Blocks, block)
 		cs.scope.blocks[decl.name] = block
+		cs.blockNames = append(cs.blockNames, decl.name)
+	case ShapeDecl:
+		decl := statement.data.(ShapeDeclaration)
+
+		if cs.scope.Type != SlideScope {
+			return tokenErrorInfo(statement.token, compilation, "A shape may only be defined within a slide")
+		}
+
+		kinds := map[TokenType]types.ShapeKind{
+			Rect:    types.Rectangle,
+			Ellipse: types.Ellipse,
+			Line:    types.Line,
+			Arrow:   types.Arrow,
+		}
+
+		shape := types.NewShape(kinds[decl.kind])
+		cs.shape = &shape
+
+		// Copy the colors of each parent in turn, keeping the shape's own position
+		for _, parentName := range decl.parents {
+			parent, ok := cs.scope.shapes[parentName]
+			if !ok {
+				return tokenErrorInfo(statement.token, compilation, "Cannot inherit from an undefined shape")
+			}
+
+			shape.Fill = parent.Fill
+			shape.Stroke = parent.Stroke
+			shape.StrokeWidth = parent.StrokeWidth
+		}
+
+		cs.openScope(ShapeScope)
+		for _, statement := range decl.statements {
+			if err := cs.processStatement(statement); err != nil {
+				return err
+			}
+		}
+		cs.closeScope()
+
+		cs.slide.Shapes = append(cs.slide.Shapes, shape)
+		cs.scope.shapes[decl.name] = shape
+	case StyleDecl:
+		// Styles are gathered before compilation starts, so we
+		// need only check that this one was in the right place
+		if cs.scope.Type != FileScope {
+			return tokenErrorInfo(statement.token, compilation, "A style may only be defined at the top level")
+		}
 	case WordBlock:
 		if cs.scope.Type != BlockScope {
 			return tokenErrorInfo(statement.token, compilation, "Text may only be defined within a block")
 		}
 
-		cs.block.Words = statement.data.(string)
-	case VariableDeclaration:
-		variable := statement.data.(VariableDeclStatement)
+		if cs.block.Code != nil {
+			cs.block.Words = trimCode(statement.data.(string))
+		} else if cs.block.Table != nil {
+			cs.block.Words = trimCode(statement.data.(string))
+
+			rows, err := tableRowsFromText(statement.token, cs.block.Words)
+			if err != nil {
+				return err
+			}
+
+			cs.block.Table.Rows = rows
+		} else if cs.block.Chart != nil {
+			cs.block.Words = trimCode(statement.data.(string))
 
-		var value interface{}
-		switch data := variable.value.(type) {
-		case uint8, string, ColorLiteral:
-			value = data
-		case VariableReference:
-			dereferenced, err := cs.scope.getVariable(statement.token, data.reference)
+			rows, err := csvRows(strings.NewReader(cs.block.Words))
 			if err != nil {
+				message := fmt.Sprintf("Malformed chart data: %s", err)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			if err := chartDataFromRows(statement.token, rows, cs.block.Chart); err != nil {
 				return err
 			}
+		} else if cs.block.Diagram != nil {
+			cs.block.Words = trimCode(statement.data.(string))
+
+			// Text from a placeholder is replaced rather than added to
+			cs.block.Diagram.Nodes = nil
+			cs.block.Diagram.Edges = nil
+			if err := diagram.Parse(cs.block.Words, cs.block.Diagram); err != nil {
+				message := fmt.Sprintf("Malformed diagram: %s", err)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+		} else {
+			cs.block.Words = statement.data.(string)
+
+			// Formulas are converted as the slides are rendered, but
+			// any mistakes in them are better caught here
+			for _, segment := range tex.Split(cs.block.Words) {
+				if !segment.Math {
+					continue
+				}
+
+				if _, err := tex.MathML(segment.Text, segment.Display); err != nil {
+					message := fmt.Sprintf("Malformed math: %s", err)
+					return tokenErrorInfo(statement.token, compilation, message)
+				}
+			}
+		}
+	case VariableDeclaration:
+		variable := statement.data.(VariableDeclStatement)
 
-			value = dereferenced
+		value, err := cs.resolveValue(statement.token, variable.value)
+		if err != nil {
+			return err
 		}
 
 		if err := cs.scope.declareVariable(statement.token, variable.isMutable, variable.name, value); err != nil {
@@ -266,17 +619,9 @@ func (cs *compilationState) processStatement(statement Statement) error {
 	case VariableAssignment:
 		variable := statement.data.(VariableStatement)
 
-		var value interface{}
-		switch data := variable.value.(type) {
-		case uint8, string, ColorLiteral:
-			value = data
-		case VariableReference:
-			dereferenced, err := cs.scope.getVariable(statement.token, data.reference)
-			if err != nil {
-				return err
-			}
-
-			value = dereferenced
+		value, err := cs.resolveValue(statement.token, variable.value)
+		if err != nil {
+			return err
 		}
 
 		if err := cs.scope.setVariable(statement.token, variable.name, value); err != nil {
@@ -285,32 +630,180 @@ func (cs *compilationState) processStatement(statement Statement) error {
 	case AttributeAssignment:
 		attribute := statement.data.(AttributeStatement)
 
+		// Shapes have attributes of their own
+		if cs.scope.Type == ShapeScope {
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			return applyShapeAttribute(statement.token, attribute.name, value, cs.shape)
+		}
+
 		switch attribute.name {
+		case "header", "footer":
+			if cs.scope.Type != SlideScope {
+				message := fmt.Sprintf("%s attribute is only available for slides", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			text, ok := value.(string)
+			if !ok {
+				message := fmt.Sprintf("%s attribute must be a string", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			if attribute.name == "header" {
+				cs.slideDecorations.header = &text
+			} else {
+				cs.slideDecorations.footer = &text
+			}
+		case "notes":
+			if cs.scope.Type != SlideScope {
+				return tokenErrorInfo(statement.token, compilation, "notes attribute is only available for slides")
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			notes, ok := value.(string)
+			if !ok {
+				return tokenErrorInfo(statement.token, compilation, "notes attribute must be a string or text")
+			}
+
+			cs.slide.Notes = trimCode(notes)
+		case "slideNumber":
+			if cs.scope.Type != SlideScope {
+				return tokenErrorInfo(statement.token, compilation, "slideNumber attribute is only available for slides")
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			enabled, ok := value.(bool)
+			if !ok {
+				return tokenErrorInfo(statement.token, compilation, "slideNumber attribute must be a boolean")
+			}
+
+			cs.slideDecorations.slideNumber = &enabled
+		case "apply":
+			if cs.scope.Type != BlockScope {
+				return tokenErrorInfo(statement.token, compilation, "apply attribute is only available for blocks")
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			name, ok := value.(string)
+			if !ok {
+				return tokenErrorInfo(statement.token, compilation, "apply attribute must be the name of a style")
+			}
+
+			if err := cs.applyStyle(statement.token, name); err != nil {
+				return err
+			}
+		case "x", "y", "width", "height":
+			if cs.scope.Type != BlockScope {
+				message := fmt.Sprintf("%s attribute is only available for blocks", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			percentage, ok := value.(uint)
+			if !ok || percentage > 100 {
+				message := fmt.Sprintf("%s attribute must be a percentage between 0 and 100", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			cs.block.Positioned = true
+			switch attribute.name {
+			case "x":
+				cs.block.Frame.X = percentage
+			case "y":
+				cs.block.Frame.Y = percentage
+			case "width":
+				cs.block.Frame.Width = percentage
+			case "height":
+				cs.block.Frame.Height = percentage
+			}
 		case "backgroundColor":
 			if cs.scope.Type != SlideScope {
 				return tokenErrorInfo(statement.token, compilation, "backgroundColor attribute is only available for slides")
 			}
 
-			switch value := attribute.value.(type) {
-			case VariableReference:
-				val, err := cs.scope.getVariable(statement.token, value.reference)
-				if err != nil {
-					return err
-				}
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
 
-				c, err := colorFromLiteral(statement.token, val)
-				if err != nil {
-					return err
-				}
+			c, err := colorFromLiteral(statement.token, value)
+			if err != nil {
+				return err
+			}
 
-				cs.slide.Background = c
-			default:
-				c, err := colorFromLiteral(statement.token, value)
-				if err != nil {
-					return err
+			cs.slide.Background = c
+		case "backgroundGradient":
+			if cs.scope.Type != SlideScope {
+				return tokenErrorInfo(statement.token, compilation, "backgroundGradient attribute is only available for slides")
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			gradient, ok := value.(types.Gradient)
+			if !ok {
+				return tokenErrorInfo(statement.token, compilation, "backgroundGradient attribute must be a linearGradient or radialGradient")
+			}
+
+			cs.slide.BackgroundGradient = &gradient
+		case "backgroundImage", "backgroundFit":
+			if cs.scope.Type != SlideScope {
+				message := fmt.Sprintf("%s attribute is only available for slides", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			text, ok := value.(string)
+			if !ok {
+				message := fmt.Sprintf("%s attribute must be a string", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			if cs.slide.BackgroundImage == nil {
+				cs.slide.BackgroundImage = &types.Image{}
+			}
+
+			if attribute.name == "backgroundImage" {
+				path := cs.resolvePath(text)
+				if _, err := os.Stat(path); err != nil {
+					message := fmt.Sprintf("Unable to read background image '%s'", text)
+					return tokenErrorInfo(statement.token, compilation, message)
 				}
 
-				cs.slide.Background = c
+				cs.slide.BackgroundImage.Path = path
+			} else if cs.slide.BackgroundImage.Fit, err = imageFitFromLiteral(statement.token, text); err != nil {
+				return err
 			}
 		case "justify":
 			if cs.scope.Type != BlockScope {
@@ -343,48 +836,37 @@ func (cs *compilationState) processStatement(statement Statement) error {
 				return tokenErrorInfo(statement.token, compilation, "font attribute is only available for blocks")
 			}
 
-			switch value := attribute.value.(type) {
-			case VariableReference:
-				val, err := cs.scope.getVariable(statement.token, value.reference)
-				if err != nil {
-					return err
-				}
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
 
-				switch val := val.(type) {
-				case string:
-					cs.block.Style.Font = val
-				default:
-					return tokenErrorInfo(statement.token, compilation, "Font attribute must be a string")
-				}
+			switch value := value.(type) {
 			case string:
 				cs.block.Style.Font = value
+				cs.block.Style.FontFallbacks = nil
+			case fontStack:
+				cs.block.Style.Font = value[0]
+				cs.block.Style.FontFallbacks = value[1:]
+			default:
+				return tokenErrorInfo(statement.token, compilation, "Font attribute must be a string or fontStack")
 			}
 		case "fontColor":
 			if cs.scope.Type != BlockScope {
 				return tokenErrorInfo(statement.token, compilation, "fontColor attribute is only available for blocks")
 			}
 
-			switch value := attribute.value.(type) {
-			case VariableReference:
-				val, err := cs.scope.getVariable(statement.token, value.reference)
-				if err != nil {
-					return err
-				}
-
-				c, err := colorFromLiteral(statement.token, val)
-				if err != nil {
-					return err
-				}
-
-				cs.block.Style.Color = c
-			default:
-				c, err := colorFromLiteral(statement.token, value)
-				if err != nil {
-					return err
-				}
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
 
-				cs.block.Style.Color = c
+			c, err := colorFromLiteral(statement.token, value)
+			if err != nil {
+				return err
 			}
+
+			cs.block.Style.Color = c
 		case "fontSize":
 			if cs.scope.Type != BlockScope {
 				return tokenErrorInfo(statement.token, compilation, "fontSize attribute is only available for blocks")
@@ -397,20 +879,224 @@ func (cs *compilationState) processStatement(statement Statement) error {
 					return err
 				}
 
-				size, ok := val.(uint8)
-				if !ok {
+				size, ok := val.(uint)
+				if !ok || size > 255 {
+					return tokenErrorInfo(statement.token, compilation, "Font size attribute must be an integer")
+				}
+
+				cs.block.Style.Size = uint8(size)
+			case uint:
+				if value > 255 {
 					return tokenErrorInfo(statement.token, compilation, "Font size attribute must be an integer")
 				}
 
-				cs.block.Style.Size = size
-			case uint8:
-				cs.block.Style.Size = value
+				cs.block.Style.Size = uint8(value)
 			default:
 				return tokenErrorInfo(statement.token, compilation, "Font size attribute must be an integer")
 			}
+		case "language", "lineNumbers", "highlight", "codeTheme":
+			if cs.scope.Type != BlockScope || cs.block.Code == nil {
+				message := fmt.Sprintf("%s attribute is only available for code blocks", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			if err := applyCodeAttribute(statement.token, attribute.name, value, cs.block.Code); err != nil {
+				return err
+			}
+		case "diagramType", "direction":
+			if cs.scope.Type != BlockScope || cs.block.Diagram == nil {
+				message := fmt.Sprintf("%s attribute is only available for diagrams", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case attribute.name == "diagramType" && value == "flowchart":
+				cs.block.Diagram.Kind = types.Flowchart
+			case attribute.name == "diagramType" && value == "sequence":
+				cs.block.Diagram.Kind = types.Sequence
+			case attribute.name == "direction" && value == "down":
+				cs.block.Diagram.Direction = types.TopToBottom
+			case attribute.name == "direction" && value == "right":
+				cs.block.Diagram.Direction = types.LeftToRight
+			case attribute.name == "diagramType":
+				return tokenErrorInfo(statement.token, compilation, "diagramType attribute must be either 'flowchart' or 'sequence'")
+			default:
+				return tokenErrorInfo(statement.token, compilation, "direction attribute must be either 'down' or 'right'")
+			}
+		case "chartType", "data", "xLabel", "yLabel", "colors":
+			if cs.scope.Type != BlockScope || cs.block.Chart == nil {
+				message := fmt.Sprintf("%s attribute is only available for charts", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			if err := cs.applyChartAttribute(statement.token, attribute.name, value); err != nil {
+				return err
+			}
+		case "headerRow", "columnAlign", "border", "borderColor", "headerBackground":
+			if cs.scope.Type != BlockScope || cs.block.Table == nil {
+				message := fmt.Sprintf("%s attribute is only available for tables", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			if err := applyTableAttribute(statement.token, attribute.name, value, cs.block.Table); err != nil {
+				return err
+			}
+		case "fontWeight", "italic", "underline", "strikethrough", "letterSpacing",
+			"lineHeight", "textTransform", "verticalAlign", "padding":
+			if cs.scope.Type != BlockScope {
+				message := fmt.Sprintf("%s attribute is only available for blocks", attribute.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			value, err := cs.resolveValue(statement.token, attribute.value)
+			if err != nil {
+				return err
+			}
+
+			if err := applyTypography(statement.token, attribute.name, value, &cs.block.Style); err != nil {
+				return err
+			}
 		default:
 			return tokenErrorInfo(statement.token, compilation, "Unrecognized attribute")
 		}
+	case DirectiveAssignment:
+		directive := statement.data.(DirectiveStatement)
+
+		if cs.scope.Type != FileScope {
+			return tokenErrorInfo(statement.token, compilation, "Directives may only be used at the top level")
+		}
+
+		value, err := cs.resolveValue(statement.token, directive.value)
+		if err != nil {
+			return err
+		}
+
+		if directive.argument != "" && directive.name != "font" {
+			message := fmt.Sprintf("%s directive does not take a name", directive.name)
+			return tokenErrorInfo(statement.token, compilation, message)
+		}
+
+		switch directive.name {
+		case "theme":
+			// Themes are loaded before compilation starts, so
+			// we need only check that this one was well formed
+			if _, ok := directive.value.(string); !ok {
+				return tokenErrorInfo(statement.token, compilation, "theme directive must be a string")
+			}
+		case "aspectRatio":
+			ratio, err := aspectRatioFromLiteral(statement.token, value)
+			if err != nil {
+				return err
+			}
+
+			cs.aspectRatio = ratio
+			cs.aspectRatioToken = statement.token
+		case "width":
+			width, ok := value.(uint)
+			if !ok || width == 0 || width > types.MaxDimension {
+				message := fmt.Sprintf("width directive must be a positive integer no larger than %d", types.MaxDimension)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			cs.width = width
+			cs.widthToken = statement.token
+		case "height":
+			height, ok := value.(uint)
+			if !ok || height == 0 || height > types.MaxDimension {
+				message := fmt.Sprintf("height directive must be a positive integer no larger than %d", types.MaxDimension)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			cs.height = height
+			cs.heightToken = statement.token
+		case "title", "author", "date", "description", "lang":
+			text, ok := value.(string)
+			if !ok {
+				message := fmt.Sprintf("%s directive must be a string", directive.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			switch directive.name {
+			case "title":
+				cs.show.Metadata.Title = text
+			case "author":
+				cs.show.Metadata.Author = text
+			case "date":
+				cs.show.Metadata.Date = text
+			case "description":
+				cs.show.Metadata.Description = text
+			case "lang":
+				cs.show.Metadata.Language = text
+			}
+		case "header", "footer":
+			text, ok := value.(string)
+			if !ok {
+				message := fmt.Sprintf("%s directive must be a string", directive.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			if directive.name == "header" {
+				cs.decorations.header = text
+			} else {
+				cs.decorations.footer = text
+			}
+		case "slideNumbers", "decorateTitleSlide":
+			enabled, ok := value.(bool)
+			if !ok {
+				message := fmt.Sprintf("%s directive must be a boolean", directive.name)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			if directive.name == "slideNumbers" {
+				cs.decorations.slideNumbers = enabled
+			} else {
+				cs.decorations.decorateTitleSlide = enabled
+			}
+		case "font":
+			if directive.argument == "" {
+				return tokenErrorInfo(statement.token, compilation, "font directive must name its family (ex: @font \"Brand\" = \"brand.woff2\";)")
+			}
+
+			path, ok := value.(string)
+			if !ok {
+				return tokenErrorInfo(statement.token, compilation, "font directive must be the path to a font file")
+			}
+
+			if !isFontFile(path) {
+				message := fmt.Sprintf("Unsupported font file '%s', expected .woff2, .woff, .ttf or .otf", path)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			resolved := cs.resolvePath(path)
+			if _, err := os.Stat(resolved); err != nil {
+				message := fmt.Sprintf("Unable to read font file '%s'", path)
+				return tokenErrorInfo(statement.token, compilation, message)
+			}
+
+			cs.show.Fonts = append(cs.show.Fonts, types.FontFace{Family: directive.argument, Path: resolved})
+		default:
+			return tokenErrorInfo(statement.token, compilation, "Unrecognized directive")
+		}
 	case MacroDecl:
 		macroDef := statement.data.(MacroDeclaration)
 
@@ -435,8 +1121,303 @@ func (cs *compilationState) processStatement(statement Statement) error {
 	return nil
 }
 
-func (cs *compilationState) finalizeCompilation() types.Show {
-	return cs.show
+// Compile the body of a slide or master, starting from a copy of its parent
+func (cs *compilationState) compileSlide(token Token, name string, parentName string, statements []Statement, isMaster bool) (types.Slide, decorationOverrides, error) {
+	slide := types.NewSlide()
+	cs.slide = &slide
+
+	overrides := decorationOverrides{}
+	cs.slideDecorations = &overrides
+
+	cs.master = nil
+	cs.blockNames = make([]string, 0)
+
+	// Slides without a parent take their background and decorations
+	// from the default master, if there is one
+	if parentName == "" {
+		if parent, ok := cs.scope.getMaster(defaultName); ok && !(isMaster && name == defaultName) {
+			copyBackground(&slide, parent.slide)
+			slide.Shapes = append(slide.Shapes, parent.slide.Shapes...)
+			overrides = parent.decorations
+		}
+	}
+
+	// If the slide has a parent, copy the parent's attributes
+	if parentName != "" {
+		if parent, ok := cs.scope.slides[parentName]; ok {
+			copyBackground(&slide, parent)
+			overrides = cs.decorationsByName[parentName]
+		} else if parent, ok := cs.scope.getMaster(parentName); ok {
+			copyBackground(&slide, parent.slide)
+			slide.Shapes = append(slide.Shapes, parent.slide.Shapes...)
+			overrides = parent.decorations
+			cs.master = &parent
+		} else {
+			return slide, overrides, tokenErrorInfo(token, compilation, "Cannot inherit from an undefined slide")
+		}
+	}
+
+	cs.openScope(SlideScope)
+	for _, statement := range statements {
+		if err := cs.processStatement(statement); err != nil {
+			return slide, overrides, err
+		}
+	}
+	cs.closeScope()
+
+	// A master may choose how images are fit, leaving
+	// the image itself to the slides inheriting from it
+	if image := slide.BackgroundImage; image != nil && image.Path == "" && !isMaster {
+		return slide, overrides, tokenErrorInfo(token, compilation, "backgroundFit requires a backgroundImage")
+	}
+
+	if cs.master != nil {
+		slide.Blocks, cs.blockNames = cs.master.arrange(slide.Blocks, cs.blockNames, isMaster)
+	}
+
+	return slide, overrides, nil
+}
+
+func (cs *compilationState) declareStyle(statement Statement) error {
+	decl := statement.data.(StyleDeclaration)
+
+	if _, ok := cs.styles[decl.name]; ok && !cs.themeStyles[decl.name] {
+		return tokenErrorInfo(statement.token, compilation, "style already declared")
+	}
+
+	for _, inner := range decl.statements {
+		switch inner.Type {
+		case AttributeAssignment, VariableDeclaration, VariableAssignment, MacroCall:
+		default:
+			message := "A style may only contain attributes, variables and macro calls"
+			return tokenErrorInfo(inner.token, compilation, message)
+		}
+	}
+
+	cs.styles[decl.name] = statement
+	// Any style inheriting from this one may have been flattened with
+	// what it replaces, so nothing flattened so far can be trusted
+	if len(cs.linearizedStyles) > 0 {
+		cs.linearizedStyles = make(map[string][]Statement)
+	}
+	cs.themeStyles[decl.name] = cs.compilingTheme
+
+	return nil
+}
+
+// Apply a style's attributes (including those it inherits) to the current block
+func (cs *compilationState) applyStyle(token Token, name string) error {
+	for i, applying := range cs.applyingStyles {
+		if applying == name {
+			cycle := append(append([]string{}, cs.applyingStyles[i:]...), name)
+			message := fmt.Sprintf("Style %s applies itself: %s", name, strings.Join(cycle, " -> "))
+			return tokenErrorInfo(token, compilation, message)
+		}
+	}
+
+	statements, err := cs.linearizeStyle(token, name, nil)
+	if err != nil {
+		return err
+	}
+
+	cs.applyingStyles = append(cs.applyingStyles, name)
+	defer func() {
+		cs.applyingStyles = cs.applyingStyles[:len(cs.applyingStyles)-1]
+	}()
+
+	cs.openScope(BlockScope)
+	for _, statement := range statements {
+		if err := cs.processStatement(statement); err != nil {
+			return err
+		}
+	}
+	cs.closeScope()
+
+	return nil
+}
+
+// Flatten a style into the statements of its parents, from left to right,
+// followed by its own. Path holds the styles we are in the middle of
+// flattening so that we can report inheritance cycles
+func (cs *compilationState) linearizeStyle(token Token, name string, path []string) ([]Statement, error) {
+	for _, ancestor := range path {
+		if ancestor == name {
+			cycle := strings.Join(append(path, name), " -> ")
+			message := fmt.Sprintf("Style inheritance cycle: %s", cycle)
+			return nil, tokenErrorInfo(token, compilation, message)
+		}
+	}
+
+	if statements, ok := cs.linearizedStyles[name]; ok {
+		return statements, nil
+	}
+
+	statement, ok := cs.styles[name]
+	if !ok {
+		return nil, tokenErrorInfo(token, compilation, "Cannot apply an undefined style")
+	}
+
+	decl := statement.data.(StyleDeclaration)
+	path = append(path, name)
+
+	statements := make([]Statement, 0, len(decl.statements))
+	for _, parent := range decl.parents {
+		inherited, err := cs.linearizeStyle(statement.token, parent, path)
+		if err != nil {
+			return nil, err
+		}
+
+		statements = append(statements, inherited...)
+	}
+	statements = append(statements, decl.statements...)
+
+	cs.linearizedStyles[name] = statements
+
+	return statements, nil
+}
+
+// Resolve any variable reference into the value it refers to,
+// and evaluate any function call along with its arguments
+func (cs *compilationState) resolveValue(token Token, value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case VariableReference:
+		return cs.scope.getVariable(token, value.reference)
+	case FunctionCall:
+		function, ok := builtinFunctions[value.name]
+		if !ok {
+			message := fmt.Sprintf("Unknown function '%s'", value.name)
+			return nil, tokenErrorInfo(value.token, compilation, message)
+		}
+
+		arguments := make([]interface{}, len(value.arguments))
+		for i, argument := range value.arguments {
+			resolved, err := cs.resolveValue(value.token, argument)
+			if err != nil {
+				return nil, err
+			}
+
+			arguments[i] = resolved
+		}
+
+		return function(value.token, arguments)
+	}
+
+	return value, nil
+}
+
+func (cs *compilationState) finalizeCompilation() (types.Show, error) {
+	dimensions, err := cs.resolveDimensions()
+	if err != nil {
+		return types.Show{}, err
+	}
+
+	cs.show.Dimensions = dimensions
+	cs.resolveDecorations()
+
+	return cs.show, nil
+}
+
+// Apply the show's header, footer and numbering to each slide,
+// giving precedence to anything the slide set for itself
+func (cs *compilationState) resolveDecorations() {
+	total := len(cs.show.Slides)
+
+	for i := range cs.show.Slides {
+		slide := &cs.show.Slides[i]
+		overrides := cs.decorationsByIdx[i]
+
+		settings := cs.decorations
+		if i == 0 && !settings.decorateTitleSlide {
+			settings = decorationSettings{}
+		}
+
+		slide.Header = settings.header
+		if overrides.header != nil {
+			slide.Header = *overrides.header
+		}
+
+		slide.Footer = settings.footer
+		if overrides.footer != nil {
+			slide.Footer = *overrides.footer
+		}
+
+		numbered := settings.slideNumbers
+		if overrides.slideNumber != nil {
+			numbered = *overrides.slideNumber
+		}
+
+		if numbered {
+			slide.Number = fmt.Sprintf("%d / %d", i+1, total)
+		}
+	}
+}
+
+// Explicit widths and heights take precedence, with the aspect
+// ratio (16:9 unless declared) filling in whichever is missing
+func (cs *compilationState) resolveDimensions() (types.Dimensions, error) {
+	dimensions := types.DefaultDimensions()
+
+	ratio := cs.aspectRatio
+	if ratio.Width == 0 || ratio.Height == 0 {
+		ratio = types.Dimensions{Width: 16, Height: 9}
+	}
+
+	// Which directive to blame, should the dimension it fills in be too large
+	sizedBy, sizedByName := cs.aspectRatioToken, "aspectRatio"
+	switch {
+	case cs.width != 0 && cs.height != 0:
+		dimensions.Width = cs.width
+		dimensions.Height = cs.height
+	case cs.width != 0:
+		dimensions.Width = cs.width
+		dimensions.Height = cs.width * ratio.Height / ratio.Width
+		sizedBy, sizedByName = cs.widthToken, "width"
+	case cs.height != 0:
+		dimensions.Width = cs.height * ratio.Width / ratio.Height
+		dimensions.Height = cs.height
+		sizedBy, sizedByName = cs.heightToken, "height"
+	default:
+		dimensions.Height = dimensions.Width * ratio.Height / ratio.Width
+	}
+
+	// A ratio too lopsided for the other dimension leaves nothing to draw on
+	if dimensions.Width == 0 || dimensions.Height == 0 {
+		message := fmt.Sprintf("aspectRatio directive leaves the show %dx%d pixels, with nothing to draw on", dimensions.Width, dimensions.Height)
+		return dimensions, tokenErrorInfo(cs.aspectRatioToken, compilation, message)
+	}
+
+	if dimensions.Width > types.MaxDimension || dimensions.Height > types.MaxDimension {
+		message := fmt.Sprintf("%s directive leaves the show %dx%d pixels, larger than %d pixels a side", sizedByName, dimensions.Width, dimensions.Height, types.MaxDimension)
+		return dimensions, tokenErrorInfo(sizedBy, compilation, message)
+	}
+
+	return dimensions, nil
+}
+
+func aspectRatioFromLiteral(token Token, value interface{}) (types.Dimensions, error) {
+	message := "aspectRatio directive must be a string of the form \"width:height\", such as \"16:9\""
+
+	literal, ok := value.(string)
+	if !ok {
+		return types.Dimensions{}, tokenErrorInfo(token, compilation, message)
+	}
+
+	parts := strings.Split(literal, ":")
+	if len(parts) != 2 {
+		return types.Dimensions{}, tokenErrorInfo(token, compilation, message)
+	}
+
+	width, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 32)
+	if err != nil || width == 0 {
+		return types.Dimensions{}, tokenErrorInfo(token, compilation, message)
+	}
+
+	height, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+	if err != nil || height == 0 {
+		return types.Dimensions{}, tokenErrorInfo(token, compilation, message)
+	}
+
+	return types.Dimensions{Width: uint(width), Height: uint(height)}, nil
 }
 
 func justificationFromLiteral(token Token, value interface{}) (types.Justification, error) {
@@ -456,48 +1437,528 @@ func justificationFromLiteral(token Token, value interface{}) (types.Justificati
 	return types.Left, tokenErrorInfo(token, compilation, message)
 }
 
-func colorFromLiteral(token Token, value interface{}) (color.Color, error) {
-	switch value := value.(type) {
-	case string:
+// Set one of the typographic attributes of a style from its resolved value
+func applyTypography(token Token, name string, value interface{}, style *types.Style) error {
+	switch name {
+	case "fontWeight":
+		switch value := value.(type) {
+		case string:
+			switch value {
+			case "normal":
+				style.Weight = 400
+				return nil
+			case "bold":
+				style.Weight = 700
+				return nil
+			}
+		case uint:
+			if value >= 1 && value <= 1000 {
+				style.Weight = uint16(value)
+				return nil
+			}
+		}
+
+		return tokenErrorInfo(token, compilation, "fontWeight attribute must be 'normal', 'bold', or an integer between 1 and 1000")
+	case "italic", "underline", "strikethrough":
+		flag, ok := value.(bool)
+		if !ok {
+			message := fmt.Sprintf("%s attribute must be a boolean", name)
+			return tokenErrorInfo(token, compilation, message)
+		}
+
+		switch name {
+		case "italic":
+			style.Italic = flag
+		case "underline":
+			style.Underline = flag
+		case "strikethrough":
+			style.Strikethrough = flag
+		}
+	case "letterSpacing":
+		spacing, ok := numberFromLiteral(value)
+		if !ok {
+			return tokenErrorInfo(token, compilation, "letterSpacing attribute must be a number of pixels")
+		}
+
+		style.LetterSpacing = spacing
+	case "lineHeight":
+		height, ok := numberFromLiteral(value)
+		if !ok || height == 0 {
+			return tokenErrorInfo(token, compilation, "lineHeight attribute must be a positive multiple of the font size")
+		}
+
+		style.LineHeight = height
+	case "textTransform":
+		transforms := map[string]types.TextTransform{
+			"none":       types.NoTransform,
+			"uppercase":  types.Uppercase,
+			"lowercase":  types.Lowercase,
+			"capitalize": types.Capitalize,
+		}
+
+		text, _ := value.(string)
+		transform, ok := transforms[text]
+		if !ok {
+			return tokenErrorInfo(token, compilation, "textTransform attribute must be either 'none', 'uppercase', 'lowercase', or 'capitalize'")
+		}
+
+		style.Transform = transform
+	case "verticalAlign":
+		alignments := map[string]types.VerticalAlignment{
+			"top":    types.Top,
+			"middle": types.Middle,
+			"bottom": types.Bottom,
+		}
+
+		text, _ := value.(string)
+		alignment, ok := alignments[text]
+		if !ok {
+			return tokenErrorInfo(token, compilation, "verticalAlign attribute must be either 'top', 'middle', or 'bottom'")
+		}
+
+		style.VerticalAlignment = alignment
+	case "padding":
+		padding, ok := value.(uint)
+		if !ok {
+			return tokenErrorInfo(token, compilation, "padding attribute must be an integer number of pixels")
+		}
+
+		style.Padding = padding
+	}
+
+	return nil
+}
+
+func applyCodeAttribute(token Token, name string, value interface{}, code *types.Code) error {
+	switch name {
+	case "language":
+		language, ok := value.(string)
+		if !ok {
+			return tokenErrorInfo(token, compilation, "language attribute must be a string")
+		}
+
+		if language != "" && !highlight.Supported(language) {
+			message := fmt.Sprintf("Unsupported language '%s', expected one of %s", language, strings.Join(highlight.Languages(), ", "))
+			return tokenErrorInfo(token, compilation, message)
+		}
+
+		code.Language = language
+	case "lineNumbers":
+		enabled, ok := value.(bool)
+		if !ok {
+			return tokenErrorInfo(token, compilation, "lineNumbers attribute must be a boolean")
+		}
+
+		code.LineNumbers = enabled
+	case "highlight":
+		ranges, err := lineRangesFromLiteral(token, value)
+		if err != nil {
+			return err
+		}
+
+		code.Highlights = ranges
+	case "codeTheme":
 		switch value {
-		case "white":
-			return color.White, nil
-		case "black":
-			return color.Black, nil
-		case "red":
-			return color.RGBA{
-				R: 255,
-				G: 0,
-				B: 0,
-				A: 255,
-			}, nil
-		case "blue":
-			return color.RGBA{
-				R: 0,
-				G: 0,
-				B: 255,
-				A: 255,
-			}, nil
-		case "green":
-			return color.RGBA{
-				R: 0,
-				G: 255,
-				B: 0,
-				A: 255,
-			}, nil
+		case "light":
+			code.Theme = types.LightCode
+		case "dark":
+			code.Theme = types.DarkCode
 		default:
-			message := fmt.Sprintf("Unsupported color '%s'", value)
+			return tokenErrorInfo(token, compilation, "codeTheme attribute must be either 'light' or 'dark'")
+		}
+	}
+
+	return nil
+}
+
+// Parse a single line number, or a list of lines and
+// ranges of lines separated by commas (ex: "1, 4-6")
+func lineRangesFromLiteral(token Token, value interface{}) ([]types.LineRange, error) {
+	message := "highlight attribute must be a line number or a list of lines (ex: \"1, 4-6\")"
+
+	switch value := value.(type) {
+	case uint:
+		if value == 0 {
 			return nil, tokenErrorInfo(token, compilation, message)
 		}
-	case ColorLiteral:
-		return color.RGBA{
-			R: value.r,
-			G: value.g,
-			B: value.b,
-			A: value.a,
-		}, nil
+
+		return []types.LineRange{{Start: value, End: value}}, nil
+	case string:
+		ranges := make([]types.LineRange, 0)
+		for _, part := range strings.Split(value, ",") {
+			bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+
+			start, err := strconv.ParseUint(strings.TrimSpace(bounds[0]), 10, 32)
+			if err != nil || start == 0 {
+				return nil, tokenErrorInfo(token, compilation, message)
+			}
+
+			end := start
+			if len(bounds) == 2 {
+				if end, err = strconv.ParseUint(strings.TrimSpace(bounds[1]), 10, 32); err != nil || end < start {
+					return nil, tokenErrorInfo(token, compilation, message)
+				}
+			}
+
+			ranges = append(ranges, types.LineRange{Start: uint(start), End: uint(end)})
+		}
+
+		return ranges, nil
+	}
+
+	return nil, tokenErrorInfo(token, compilation, message)
+}
+
+func applyShapeAttribute(token Token, name string, value interface{}, shape *types.Shape) error {
+	isLine := shape.Kind == types.Line || shape.Kind == types.Arrow
+
+	switch name {
+	case "x", "y", "width", "height", "x1", "y1", "x2", "y2":
+		// Rectangles and ellipses are framed like blocks, while
+		// lines and arrows are drawn between two points
+		isPoint := strings.HasSuffix(name, "1") || strings.HasSuffix(name, "2")
+		if isLine != isPoint {
+			message := fmt.Sprintf("%s attribute is not available for %s shapes", name, strings.ToLower(shape.Kind.String()))
+			return tokenErrorInfo(token, compilation, message)
+		}
+
+		percentage, ok := value.(uint)
+		if !ok || percentage > 100 {
+			message := fmt.Sprintf("%s attribute must be a percentage between 0 and 100", name)
+			return tokenErrorInfo(token, compilation, message)
+		}
+
+		switch name {
+		case "x":
+			shape.Frame.X = percentage
+		case "y":
+			shape.Frame.Y = percentage
+		case "width":
+			shape.Frame.Width = percentage
+		case "height":
+			shape.Frame.Height = percentage
+		case "x1":
+			shape.From.X = percentage
+		case "y1":
+			shape.From.Y = percentage
+		case "x2":
+			shape.To.X = percentage
+		case "y2":
+			shape.To.Y = percentage
+		}
+	case "fill", "stroke":
+		if isLine && name == "fill" {
+			message := fmt.Sprintf("fill attribute is not available for %s shapes", strings.ToLower(shape.Kind.String()))
+			return tokenErrorInfo(token, compilation, message)
+		}
+
+		var c color.Color
+		if text, ok := value.(string); !ok || text != "none" {
+			var err error
+			if c, err = colorFromLiteral(token, value); err != nil {
+				return err
+			}
+		}
+
+		if name == "fill" {
+			shape.Fill = c
+		} else {
+			shape.Stroke = c
+		}
+	case "strokeWidth":
+		width, ok := value.(uint)
+		if !ok {
+			return tokenErrorInfo(token, compilation, "strokeWidth attribute must be an integer number of pixels")
+		}
+
+		shape.StrokeWidth = width
 	default:
-		fmt.Printf("ff %v\n", value)
-		return nil, tokenErrorInfo(token, compilation, "Color attribute must be either a tuple or string")
+		message := fmt.Sprintf("Unrecognized shape attribute '%s'", name)
+		return tokenErrorInfo(token, compilation, message)
 	}
+
+	return nil
+}
+
+func applyTableAttribute(token Token, name string, value interface{}, table *types.Table) error {
+	switch name {
+	case "headerRow":
+		enabled, ok := value.(bool)
+		if !ok {
+			return tokenErrorInfo(token, compilation, "headerRow attribute must be a boolean")
+		}
+
+		table.HeaderRow = enabled
+	case "columnAlign":
+		alignments, ok := value.(string)
+		if !ok {
+			return tokenErrorInfo(token, compilation, "columnAlign attribute must be a list of justifications (ex: \"left, right\")")
+		}
+
+		table.ColumnAlignments = make([]types.Justification, 0)
+		for _, alignment := range strings.Split(alignments, ",") {
+			justification, err := justificationFromLiteral(token, strings.TrimSpace(alignment))
+			if err != nil {
+				return err
+			}
+
+			table.ColumnAlignments = append(table.ColumnAlignments, justification)
+		}
+	case "border":
+		width, ok := value.(uint)
+		if !ok {
+			return tokenErrorInfo(token, compilation, "border attribute must be an integer number of pixels")
+		}
+
+		table.Border = width
+	case "borderColor", "headerBackground":
+		c, err := colorFromLiteral(token, value)
+		if err != nil {
+			return err
+		}
+
+		if name == "borderColor" {
+			table.BorderColor = c
+		} else {
+			table.HeaderBackground = c
+		}
+	}
+
+	return nil
+}
+
+// Split the text of a table into rows of comma separated cells. Cells may
+// be quoted to include commas, as in a CSV file
+func tableRowsFromText(token Token, text string) ([][]string, error) {
+	rows, err := csvRows(strings.NewReader(text))
+	if err != nil {
+		message := fmt.Sprintf("Malformed table: %s", err)
+		return nil, tokenErrorInfo(token, compilation, message)
+	}
+
+	return rows, nil
+}
+
+// Read comma separated rows, padding short rows so
+// that every row has the same number of cells
+func csvRows(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	for i, row := range rows {
+		for j, cell := range row {
+			row[j] = strings.TrimSpace(cell)
+		}
+
+		for len(row) < columns {
+			row = append(row, "")
+		}
+
+		rows[i] = row
+	}
+
+	return rows, nil
+}
+
+func (cs *compilationState) applyChartAttribute(token Token, name string, value interface{}) error {
+	chart := cs.block.Chart
+
+	switch name {
+	case "chartType":
+		switch value {
+		case "bar":
+			chart.Kind = types.BarChart
+		case "line":
+			chart.Kind = types.LineChart
+		case "pie":
+			chart.Kind = types.PieChart
+		default:
+			return tokenErrorInfo(token, compilation, "chartType attribute must be either 'bar', 'line', or 'pie'")
+		}
+	case "data":
+		path, ok := value.(string)
+		if !ok || path == "" {
+			return tokenErrorInfo(token, compilation, "data attribute must be the path to a CSV file")
+		}
+
+		file, err := os.Open(cs.resolvePath(path))
+		if err != nil {
+			message := fmt.Sprintf("Unable to read chart data: %s", err)
+			return tokenErrorInfo(token, compilation, message)
+		}
+		defer file.Close()
+
+		rows, err := csvRows(file)
+		if err != nil {
+			message := fmt.Sprintf("Malformed chart data in %s: %s", path, err)
+			return tokenErrorInfo(token, compilation, message)
+		}
+
+		return chartDataFromRows(token, rows, chart)
+	case "xLabel", "yLabel":
+		label, ok := value.(string)
+		if !ok {
+			message := fmt.Sprintf("%s attribute must be a string", name)
+			return tokenErrorInfo(token, compilation, message)
+		}
+
+		if name == "xLabel" {
+			chart.XLabel = label
+		} else {
+			chart.YLabel = label
+		}
+	case "colors":
+		if colors, ok := value.(colorPalette); ok {
+			chart.Colors = colors
+			break
+		}
+
+		c, err := colorFromLiteral(token, value)
+		if err != nil {
+			return tokenErrorInfo(token, compilation, "colors attribute must be a color or a palette of colors")
+		}
+
+		chart.Colors = []color.Color{c}
+	}
+
+	return nil
+}
+
+// The first row of a chart's data names each series, after the
+// name of the categories. Every other row starts with a category,
+// followed by its value in each series
+func chartDataFromRows(token Token, rows [][]string, chart *types.Chart) error {
+	if len(rows) < 2 || len(rows[0]) < 2 {
+		return tokenErrorInfo(token, compilation, "Chart data needs a header row and at least one row of values")
+	}
+
+	chart.Categories = make([]string, 0, len(rows)-1)
+	chart.Series = make([]types.ChartSeries, len(rows[0])-1)
+	for i, name := range rows[0][1:] {
+		chart.Series[i] = types.ChartSeries{Name: name, Values: make([]float64, 0, len(rows)-1)}
+	}
+
+	for _, row := range rows[1:] {
+		chart.Categories = append(chart.Categories, row[0])
+
+		for i, cell := range row[1:] {
+			value, err := strconv.ParseFloat(cell, 64)
+			if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+				message := fmt.Sprintf("Chart value %q for %s is not a number", cell, row[0])
+				return tokenErrorInfo(token, compilation, message)
+			}
+
+			chart.Series[i].Values = append(chart.Series[i].Values, value)
+		}
+	}
+
+	return nil
+}
+
+// Strip the line breaks surrounding code in a text block, along with
+// the indentation every line shares, leaving the code itself untouched
+func trimCode(text string) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) > 1 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent, found := "", false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			indent, found = lineIndent, true
+			continue
+		}
+
+		for !strings.HasPrefix(lineIndent, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+
+	for i, line := range lines {
+		if strings.HasPrefix(line, indent) {
+			lines[i] = line[len(indent):]
+		} else {
+			// Only blank lines can lack the shared indentation
+			lines[i] = ""
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Integers and decimals are interchangeable wherever a number is expected
+func numberFromLiteral(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case uint:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+
+	return 0, false
+}
+
+func isFontFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".woff2", ".woff", ".ttf", ".otf":
+		return true
+	}
+
+	return false
+}
+
+func imageFitFromLiteral(token Token, value string) (types.ImageFit, error) {
+	switch value {
+	case "fit":
+		return types.Fit, nil
+	case "cover":
+		return types.Cover, nil
+	case "tile":
+		return types.Tile, nil
+	}
+
+	message := "backgroundFit attribute must be either 'fit', 'cover', or 'tile'"
+	return types.Fit, tokenErrorInfo(token, compilation, message)
+}
+
+// Copy every layer of the parent's background onto the slide
+func copyBackground(slide *types.Slide, parent types.Slide) {
+	slide.Background = parent.Background
+	slide.BackgroundGradient = parent.BackgroundGradient
+
+	// The image is copied since its fit may be changed independently
+	if parent.BackgroundImage != nil {
+		image := *parent.BackgroundImage
+		slide.BackgroundImage = &image
+	}
+}
+
+func (cs *compilationState) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(cs.baseDir, path)
 }
@@ -0,0 +1,298 @@
+package lang
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// A function which may be called from within a value, evaluated
+// by the compiler with its (already resolved) arguments
+type builtinFunction func(token Token, arguments []interface{}) (interface{}, error)
+
+var builtinFunctions = map[string]builtinFunction{
+	"hsl":        hslFunction,
+	"lighten":    lightenFunction,
+	"darken":     darkenFunction,
+	"mix":        mixFunction,
+	"withAlpha":  withAlphaFunction,
+	"complement": complementFunction,
+
+	"linearGradient": linearGradientFunction,
+	"radialGradient": radialGradientFunction,
+
+	"fontStack": fontStackFunction,
+	"palette":   paletteFunction,
+}
+
+// hsl(hue, saturation, lightness) or hsl(hue, saturation, lightness, alpha)
+//
+// The hue is given in degrees, saturation and lightness as percentages,
+// and alpha between 0 (transparent) and 1 (opaque) as in CSS
+func hslFunction(token Token, arguments []interface{}) (interface{}, error) {
+	if len(arguments) != 3 && len(arguments) != 4 {
+		return nil, functionErrorInfo(token, "hsl", "expects a hue, saturation, lightness and optional alpha")
+	}
+
+	components := make([]uint, 3)
+	for i, argument := range arguments[:3] {
+		component, ok := argument.(uint)
+		if !ok {
+			return nil, functionErrorInfo(token, "hsl", "hue, saturation and lightness must be integers")
+		}
+
+		components[i] = component
+	}
+
+	if components[1] > 100 || components[2] > 100 {
+		return nil, functionErrorInfo(token, "hsl", "saturation and lightness must be percentages between 0 and 100")
+	}
+
+	alpha := uint8(255)
+	if len(arguments) == 4 {
+		fraction, err := fractionArgument(token, "hsl", "alpha", arguments[3])
+		if err != nil {
+			return nil, err
+		}
+
+		alpha = toByte(fraction)
+	}
+
+	r, g, b := hslToRGB(float64(components[0]), float64(components[1])/100, float64(components[2])/100)
+
+	return literalColor(toByte(r), toByte(g), toByte(b), alpha), nil
+}
+
+// lighten(color, amount)
+//
+// Raises the lightness of a color by an amount between 0 and 1,
+// so lighten(c, 0.2) is twenty percentage points lighter
+func lightenFunction(token Token, arguments []interface{}) (interface{}, error) {
+	return adjustLightness(token, "lighten", arguments, 1)
+}
+
+// darken(color, amount)
+//
+// Lowers the lightness of a color by an amount between 0 and 1
+func darkenFunction(token Token, arguments []interface{}) (interface{}, error) {
+	return adjustLightness(token, "darken", arguments, -1)
+}
+
+func adjustLightness(token Token, name string, arguments []interface{}, direction float64) (interface{}, error) {
+	if len(arguments) != 2 {
+		return nil, functionErrorInfo(token, name, "expects a color and an amount")
+	}
+
+	c, err := colorArgument(token, name, arguments[0])
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := fractionArgument(token, name, "amount", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+
+	h, s, l := rgbToHSL(float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+	r, g, b := hslToRGB(h, s, math.Max(0, math.Min(1, l+direction*amount)))
+
+	return literalColor(toByte(r), toByte(g), toByte(b), c.A), nil
+}
+
+// mix(a, b, t)
+//
+// Blends two colors, moving from a (t = 0) to b (t = 1)
+func mixFunction(token Token, arguments []interface{}) (interface{}, error) {
+	if len(arguments) != 3 {
+		return nil, functionErrorInfo(token, "mix", "expects two colors and a weight")
+	}
+
+	a, err := colorArgument(token, "mix", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := colorArgument(token, "mix", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := fractionArgument(token, "mix", "weight", arguments[2])
+	if err != nil {
+		return nil, err
+	}
+
+	blend := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x)*(1-t) + float64(y)*t))
+	}
+
+	return literalColor(blend(a.R, b.R), blend(a.G, b.G), blend(a.B, b.B), blend(a.A, b.A)), nil
+}
+
+// withAlpha(color, alpha)
+//
+// Replaces the alpha of a color with one between 0 (transparent)
+// and 1 (opaque)
+func withAlphaFunction(token Token, arguments []interface{}) (interface{}, error) {
+	if len(arguments) != 2 {
+		return nil, functionErrorInfo(token, "withAlpha", "expects a color and an alpha")
+	}
+
+	c, err := colorArgument(token, "withAlpha", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+
+	alpha, err := fractionArgument(token, "withAlpha", "alpha", arguments[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return literalColor(c.R, c.G, c.B, toByte(alpha)), nil
+}
+
+// complement(color)
+//
+// The color on the opposite side of the color wheel
+func complementFunction(token Token, arguments []interface{}) (interface{}, error) {
+	if len(arguments) != 1 {
+		return nil, functionErrorInfo(token, "complement", "expects a single color")
+	}
+
+	c, err := colorArgument(token, "complement", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+
+	h, s, l := rgbToHSL(float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+	r, g, b := hslToRGB(h+180, s, l)
+
+	return literalColor(toByte(r), toByte(g), toByte(b), c.A), nil
+}
+
+// linearGradient(angle, color, color, ...)
+//
+// The angle is given in degrees clockwise from the top, so
+// linearGradient(180, a, b) starts with a at the top of the slide
+func linearGradientFunction(token Token, arguments []interface{}) (interface{}, error) {
+	if len(arguments) < 3 {
+		return nil, functionErrorInfo(token, "linearGradient", "expects an angle and at least two colors")
+	}
+
+	angle, ok := arguments[0].(uint)
+	if !ok {
+		return nil, functionErrorInfo(token, "linearGradient", "angle must be an integer")
+	}
+
+	stops, err := gradientStops(token, "linearGradient", arguments[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return types.Gradient{Kind: types.LinearGradient, Angle: angle % 360, Stops: stops}, nil
+}
+
+// radialGradient(color, color, ...)
+//
+// The first color is at the center of the slide, the last at its edges
+func radialGradientFunction(token Token, arguments []interface{}) (interface{}, error) {
+	if len(arguments) < 2 {
+		return nil, functionErrorInfo(token, "radialGradient", "expects at least two colors")
+	}
+
+	stops, err := gradientStops(token, "radialGradient", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.Gradient{Kind: types.RadialGradient, Stops: stops}, nil
+}
+
+func gradientStops(token Token, name string, arguments []interface{}) ([]color.Color, error) {
+	stops := make([]color.Color, len(arguments))
+	for i, argument := range arguments {
+		c, err := colorArgument(token, name, argument)
+		if err != nil {
+			return nil, err
+		}
+
+		stops[i] = literalColor(c.R, c.G, c.B, c.A)
+	}
+
+	return stops, nil
+}
+
+// A list of font families, in order of preference
+type fontStack []string
+
+// fontStack(family, family, ...)
+//
+// Falls back to each family in turn when the ones before it are unavailable
+func fontStackFunction(token Token, arguments []interface{}) (interface{}, error) {
+	if len(arguments) == 0 {
+		return nil, functionErrorInfo(token, "fontStack", "expects at least one font family")
+	}
+
+	families := make(fontStack, len(arguments))
+	for i, argument := range arguments {
+		family, ok := argument.(string)
+		if !ok || family == "" {
+			return nil, functionErrorInfo(token, "fontStack", "font families must be strings")
+		}
+
+		families[i] = family
+	}
+
+	return families, nil
+}
+
+// A list of colors, used in turn (ex: for each series of a chart)
+type colorPalette []color.Color
+
+// palette(color, color, ...)
+func paletteFunction(token Token, arguments []interface{}) (interface{}, error) {
+	if len(arguments) == 0 {
+		return nil, functionErrorInfo(token, "palette", "expects at least one color")
+	}
+
+	colors := make(colorPalette, len(arguments))
+	for i, argument := range arguments {
+		c, err := colorArgument(token, "palette", argument)
+		if err != nil {
+			return nil, err
+		}
+
+		colors[i] = c
+	}
+
+	return colors, nil
+}
+
+func colorArgument(token Token, name string, argument interface{}) (color.NRGBA, error) {
+	c, err := colorFromLiteral(token, argument)
+	if err != nil {
+		return color.NRGBA{}, functionErrorInfo(token, name, "expects a tuple, hex code, color name or color variable")
+	}
+
+	return color.NRGBAModel.Convert(c).(color.NRGBA), nil
+}
+
+// Accepts either an integer or a decimal between 0 and 1
+func fractionArgument(token Token, name string, parameter string, argument interface{}) (float64, error) {
+	fraction, ok := numberFromLiteral(argument)
+	if !ok {
+		return 0, functionErrorInfo(token, name, fmt.Sprintf("%s must be a number", parameter))
+	}
+
+	if fraction > 1 {
+		return 0, functionErrorInfo(token, name, fmt.Sprintf("%s must be between 0 and 1", parameter))
+	}
+
+	return fraction, nil
+}
+
+func functionErrorInfo(token Token, name string, message string) ErrorInfo {
+	return tokenErrorInfo(token, compilation, fmt.Sprintf("%s %s", name, message))
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 type TokenType int
@@ -34,8 +35,18 @@ const (
 	Let
 	Mut
 	Macro
+	Master
 	Slide
 	Block
+	Code
+	Table
+	Diagram
+	Chart
+	Rect
+	Ellipse
+	Line
+	Arrow
+	Style
 	Self
 
 	// Literals
@@ -44,6 +55,9 @@ const (
 	Text
 	String
 	Integer
+	Decimal
+	Boolean
+	HexColor
 )
 
 func (t TokenType) String() string {
@@ -67,8 +81,18 @@ func (t TokenType) String() string {
 		"Let",
 		"Mut",
 		"Macro",
+		"Master",
 		"Slide",
 		"Block",
+		"Code",
+		"Table",
+		"Diagram",
+		"Chart",
+		"Rect",
+		"Ellipse",
+		"Line",
+		"Arrow",
+		"Style",
 		"Self",
 
 		"Identifier",
@@ -76,6 +100,9 @@ func (t TokenType) String() string {
 		"Text",
 		"String",
 		"Integer",
+		"Decimal",
+		"Boolean",
+		"HexColor",
 	}[t]
 }
 
@@ -103,7 +130,12 @@ func (lex DefaultLexer) Lex(reader io.Reader) ([]Token, error) {
 	tokens := make([]Token, 0, 1024)
 
 	for !muncher.atEnd() {
-		token, err := processRune(muncher)
+		previous := InvalidToken
+		if len(tokens) > 0 {
+			previous = tokens[len(tokens)-1].Type
+		}
+
+		token, err := processRune(muncher, previous)
 		if err != nil && errors.As(err, &ErrorInfo{}) {
 			errBundle.Add(err.(ErrorInfo))
 		} else if err != nil {
@@ -124,7 +156,7 @@ func (lex DefaultLexer) Lex(reader io.Reader) ([]Token, error) {
 	return tokens, nil
 }
 
-func processRune(muncher *runeMuncher) (Token, error) {
+func processRune(muncher *runeMuncher, previous TokenType) (Token, error) {
 	char, _, err := muncher.ReadRune()
 	if err != nil {
 		return Token{}, err
@@ -132,6 +164,15 @@ func processRune(muncher *runeMuncher) (Token, error) {
 
 	switch char {
 	case '#':
+		// Where a value is expected, a hex digit makes this the
+		// start of a hex color (ex: #ff0000) rather than a comment
+		switch previous {
+		case EqualSign, LeftParen, Comma:
+			if muncher.atHexDigit() {
+				return hexColor(muncher, char)
+			}
+		}
+
 		_, _, _ = muncher.ReadLine()
 		muncher.newLine()
 		return Token{Type: Skip}, nil
@@ -224,6 +265,16 @@ func processRune(muncher *runeMuncher) (Token, error) {
 				line:   muncher.line,
 				lexeme: char,
 			}, nil
+		} else if ok, err := muncher.eatKeyword("ine"); err == io.EOF {
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected end of file")
+		} else if err != nil {
+			return Token{}, err
+		} else if ok {
+			return Token{
+				Type:   Line,
+				line:   muncher.line,
+				lexeme: char,
+			}, nil
 		}
 
 	case 'm':
@@ -247,6 +298,16 @@ func processRune(muncher *runeMuncher) (Token, error) {
 				line:   muncher.line,
 				lexeme: char,
 			}, nil
+		} else if ok, err := muncher.eatKeyword("aster"); err == io.EOF {
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected end of file")
+		} else if err != nil {
+			return Token{}, err
+		} else if ok {
+			return Token{
+				Type:   Master,
+				line:   muncher.line,
+				lexeme: char,
+			}, nil
 		}
 
 	case 's':
@@ -270,6 +331,104 @@ func processRune(muncher *runeMuncher) (Token, error) {
 				line:   muncher.line,
 				lexeme: char,
 			}, nil
+		} else if ok, err := muncher.eatKeyword("tyle"); err == io.EOF {
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected end of file")
+		} else if err != nil {
+			return Token{}, err
+		} else if ok {
+			return Token{
+				Type:   Style,
+				line:   muncher.line,
+				lexeme: char,
+			}, nil
+		}
+
+	case 'c':
+		if ok, err := muncher.eatKeyword("ode"); err == io.EOF {
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected end of file")
+		} else if err != nil {
+			return Token{}, err
+		} else if ok {
+			return Token{
+				Type:   Code,
+				line:   muncher.line,
+				lexeme: char,
+			}, nil
+		} else if ok, err := muncher.eatKeyword("hart"); err == io.EOF {
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected end of file")
+		} else if err != nil {
+			return Token{}, err
+		} else if ok {
+			return Token{
+				Type:   Chart,
+				line:   muncher.line,
+				lexeme: char,
+			}, nil
+		}
+
+	case 't':
+		if ok, err := muncher.eatKeyword("able"); err == io.EOF {
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected end of file")
+		} else if err != nil {
+			return Token{}, err
+		} else if ok {
+			return Token{
+				Type:   Table,
+				line:   muncher.line,
+				lexeme: char,
+			}, nil
+		}
+
+	case 'd':
+		if ok, err := muncher.eatKeyword("iagram"); err == io.EOF {
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected end of file")
+		} else if err != nil {
+			return Token{}, err
+		} else if ok {
+			return Token{
+				Type:   Diagram,
+				line:   muncher.line,
+				lexeme: char,
+			}, nil
+		}
+
+	case 'r':
+		if ok, err := muncher.eatKeyword("ect"); err == io.EOF {
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected end of file")
+		} else if err != nil {
+			return Token{}, err
+		} else if ok {
+			return Token{
+				Type:   Rect,
+				line:   muncher.line,
+				lexeme: char,
+			}, nil
+		}
+
+	case 'e':
+		if ok, err := muncher.eatKeyword("llipse"); err == io.EOF {
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected end of file")
+		} else if err != nil {
+			return Token{}, err
+		} else if ok {
+			return Token{
+				Type:   Ellipse,
+				line:   muncher.line,
+				lexeme: char,
+			}, nil
+		}
+
+	case 'a':
+		if ok, err := muncher.eatKeyword("rrow"); err == io.EOF {
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected end of file")
+		} else if err != nil {
+			return Token{}, err
+		} else if ok {
+			return Token{
+				Type:   Arrow,
+				line:   muncher.line,
+				lexeme: char,
+			}, nil
 		}
 
 	case 'b':
@@ -349,8 +508,7 @@ func processRune(muncher *runeMuncher) (Token, error) {
 			data: str[:len(str)-1],
 		}, nil
 
-	case '0':
-	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 		num := strings.Builder{}
 		num.WriteRune(char)
 
@@ -369,16 +527,48 @@ func processRune(muncher *runeMuncher) (Token, error) {
 			return Token{}, err
 		}
 
-		data, err := strconv.ParseUint(num.String(), 10, 8)
+		if muncher.atFraction() {
+			num.WriteRune('.')
+			muncher.eatN(1)
+
+			err := muncher.eatWhile(func(char rune) bool {
+				if !unicode.IsNumber(char) {
+					muncher.UnreadRune()
+					return false
+				}
+
+				num.WriteRune(char)
+
+				return true
+			})
+
+			if err != nil {
+				return Token{}, err
+			}
+
+			data, err := strconv.ParseFloat(num.String(), 64)
+			if err != nil {
+				return Token{}, lexemeErrorInfo(muncher.line, char, "Decimal literal out of range")
+			}
+
+			return Token{
+				Type:   Decimal,
+				line:   muncher.line,
+				lexeme: char,
+				data:   data,
+			}, nil
+		}
+
+		data, err := strconv.ParseUint(num.String(), 10, 32)
 		if err != nil {
-			return Token{}, err
+			return Token{}, lexemeErrorInfo(muncher.line, char, "Integer literal out of range")
 		}
 
 		return Token{
 			Type:   Integer,
 			line:   muncher.line,
 			lexeme: char,
-			data:   uint8(data),
+			data:   uint(data),
 		}, nil
 
 	case ' ', '\t', '\r':
@@ -408,6 +598,16 @@ func processRune(muncher *runeMuncher) (Token, error) {
 			return Token{}, err
 		}
 
+		switch ident.String() {
+		case "true", "false":
+			return Token{
+				Type:   Boolean,
+				line:   muncher.line,
+				lexeme: char,
+				data:   ident.String() == "true",
+			}, nil
+		}
+
 		return Token{
 			Type:   Identifier,
 			line:   muncher.line,
@@ -419,6 +619,54 @@ func processRune(muncher *runeMuncher) (Token, error) {
 	return Token{}, lexemeErrorInfo(muncher.line, char, "Unexpected character")
 }
 
+// Lex a hex color of the form #rrggbb or #rrggbbaa
+func hexColor(muncher *runeMuncher, char rune) (Token, error) {
+	digits := strings.Builder{}
+
+	// The whole word is read, so that a color with a stray letter
+	// (ex: #deadbeefs) is reported rather than cut short
+	err := muncher.eatWhile(func(char rune) bool {
+		if !unicode.IsLetter(char) && !unicode.IsNumber(char) {
+			muncher.UnreadRune()
+			return false
+		}
+
+		digits.WriteRune(char)
+
+		return true
+	})
+
+	if err != nil && err != io.EOF {
+		return Token{}, err
+	}
+
+	hex := digits.String()
+	if len(hex) != 6 && len(hex) != 8 || strings.TrimLeft(hex, "0123456789abcdefABCDEF") != "" {
+		return Token{}, lexemeErrorInfo(muncher.line, char, "Hex colors must have 6 or 8 digits")
+	}
+
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Token{}, err
+	}
+
+	return Token{
+		Type:   HexColor,
+		line:   muncher.line,
+		lexeme: char,
+		data: ColorLiteral{
+			r: uint8(value >> 24),
+			g: uint8(value >> 16),
+			b: uint8(value >> 8),
+			a: uint8(value),
+		},
+	}, nil
+}
+
 type runeMuncher struct {
 	line uint
 	*bufio.Reader
@@ -455,6 +703,19 @@ func (r *runeMuncher) eatIf(expected rune) (bool, error) {
 	return false, r.UnreadRune()
 }
 
+// Check whether the upcoming rune is a hex digit
+func (r *runeMuncher) atHexDigit() bool {
+	chars, _ := r.Peek(1)
+	return len(chars) == 1 && unicode.Is(unicode.ASCII_Hex_Digit, rune(chars[0]))
+}
+
+// Check whether the upcoming runes are the fractional part
+// of a decimal literal, i.e. a dot followed by a digit
+func (r *runeMuncher) atFraction() bool {
+	chars, _ := r.Peek(2)
+	return len(chars) == 2 && chars[0] == '.' && unicode.IsDigit(rune(chars[1]))
+}
+
 // Helper function to discard a specified number of runes
 func (r *runeMuncher) eatN(n int) error {
 	for i := 0; i < n; i++ {
@@ -485,10 +746,20 @@ func (r *runeMuncher) eatKeyword(rest string) (bool, error) {
 
 	if chars, err := r.Peek(restLen); err != nil {
 		return false, err
-	} else if string(chars[:]) == rest {
-		r.eatN(restLen)
-		return true, nil
+	} else if string(chars[:]) != rest {
+		return false, nil
+	}
+
+	// Make sure we aren't looking at an identifier which
+	// merely starts with a keyword (ex: slideNumber)
+	if chars, err := r.Peek(restLen + utf8.UTFMax); len(chars) > restLen {
+		next, _ := utf8.DecodeRune(chars[restLen:])
+		if unicode.IsLetter(next) || unicode.IsNumber(next) {
+			return false, nil
+		}
+	} else if err != nil && err != io.EOF {
+		return false, err
 	}
 
-	return false, nil
+	return true, r.eatN(restLen)
 }
@@ -81,3 +81,98 @@ func TestTextBlock(t *testing.T) {
 		t.Errorf("Expected \"This is one block of text\"-- got \"%s\"", tokens[0].data)
 	}
 }
+
+func TestKeywordPrefixedIdentifier(t *testing.T) {
+	source := `slideNumber letter self true;`
+
+	reader := strings.NewReader(source)
+	tokens, err := lexer.Lex(reader)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := []TokenType{Identifier, Identifier, Self, Boolean, Semicolon}
+	if len(tokens) != len(expected) {
+		t.Errorf("Expected exactly %d tokens-- got %d", len(expected), len(tokens))
+		return
+	}
+
+	for i, token := range tokens {
+		if token.Type != expected[i] {
+			t.Errorf("Expected %s in position %d-- got %s", expected[i].String(), i+1, token.Type.String())
+		}
+	}
+}
+
+func TestHexColor(t *testing.T) {
+	source := `
+	let a = #1a535c;
+	let b = #1a535c80;
+	# cafe00 is not a color, but a comment`
+
+	reader := strings.NewReader(source)
+	tokens, err := lexer.Lex(reader)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(tokens) != 10 {
+		t.Errorf("Expected exactly ten tokens-- got %d", len(tokens))
+		return
+	}
+
+	if tokens[3].Type != HexColor {
+		t.Errorf("Expected HexColor in position 4-- got %s", tokens[3].Type.String())
+		return
+	}
+
+	value := tokens[8].data.(ColorLiteral)
+	if value.r != 26 || value.g != 83 || value.b != 92 || value.a != 128 {
+		t.Errorf("Expected (26, 83, 92, 128)-- got (%d, %d, %d, %d)", value.r, value.g, value.b, value.a)
+	}
+}
+
+func TestMalformedHexColor(t *testing.T) {
+	sources := []string{
+		`self.fontColor = #abc;`,
+		`let a = rgba(#1a535c8, 0);`,
+		`let a = #deadbeefs;`,
+	}
+
+	for _, source := range sources {
+		_, err := lexer.Lex(strings.NewReader(source))
+		if err == nil || !strings.Contains(err.Error(), "Hex colors must have 6 or 8 digits") {
+			t.Errorf("Expected %s to have a malformed hex color-- got %v", source, err)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	source := `let a = lighten(b, 0.25); self.x = 1;`
+
+	reader := strings.NewReader(source)
+	tokens, err := lexer.Lex(reader)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if tokens[7].Type != Decimal {
+		t.Errorf("Expected Decimal in position 8-- got %s", tokens[7].Type.String())
+		return
+	}
+
+	if value := tokens[7].data.(float64); value != 0.25 {
+		t.Errorf("Expected 0.25-- got %f", value)
+		return
+	}
+
+	if tokens[len(tokens)-5].Type != Dot {
+		t.Errorf("Expected Dot after self-- got %s", tokens[len(tokens)-5].Type.String())
+	}
+}
@@ -11,13 +11,17 @@ const (
 	InvalidStatement StatementType = iota
 
 	SlideDecl
+	MasterDecl
 	BlockDecl
+	ShapeDecl
+	StyleDecl
 	MacroDecl
 
 	VariableDeclaration
 
 	VariableAssignment
 	AttributeAssignment
+	DirectiveAssignment
 
 	WordBlock
 	MacroCall
@@ -28,13 +32,17 @@ func (s StatementType) String() string {
 		"InvalidStatement",
 
 		"SlideDecl",
+		"MasterDecl",
 		"BlockDecl",
+		"ShapeDecl",
+		"StyleDecl",
 		"MacroDecl",
 
 		"VariableDeclaration",
 
 		"VariableAssignment",
 		"AttributeAssignment",
+		"DirectiveAssignment",
 
 		"WordBlock",
 		"MacroCall",
@@ -63,18 +71,47 @@ type AttributeStatement struct {
 	value interface{}
 }
 
+type DirectiveStatement struct {
+	name string
+	// Some directives are qualified by a string (ex: @font "Brand" = ...)
+	argument string
+	value    interface{}
+}
+
 type SlideDeclaration struct {
 	name       string
 	parent     string
 	statements []Statement
 }
 
-type BlockDeclaration struct {
+type MasterDeclaration struct {
 	name       string
 	parent     string
 	statements []Statement
 }
 
+type BlockDeclaration struct {
+	name string
+	// The keyword which declared the block (ex: Block, Code or Table)
+	kind       TokenType
+	parents    []string
+	statements []Statement
+}
+
+type ShapeDeclaration struct {
+	name string
+	// The keyword which declared the shape (ex: Rect or Arrow)
+	kind       TokenType
+	parents    []string
+	statements []Statement
+}
+
+type StyleDeclaration struct {
+	name       string
+	parents    []string
+	statements []Statement
+}
+
 type MacroDeclaration struct {
 	name       string
 	statements []Statement
@@ -88,6 +125,14 @@ type MacroInvocation struct {
 	reference string
 }
 
+// A call to one of the compiler's built-in functions, which
+// are evaluated during compilation (ex: hsl(200, 50, 40))
+type FunctionCall struct {
+	token     Token
+	name      string
+	arguments []interface{}
+}
+
 type ColorLiteral struct {
 	r uint8
 	g uint8
@@ -146,7 +191,7 @@ func block(muncher *tokenMuncher) (Statement, error) {
 	token := muncher.peek()
 
 	switch token.Type {
-	case Slide, Block, Macro:
+	case Slide, Master, Block, Code, Table, Diagram, Chart, Rect, Ellipse, Line, Arrow, Style, Macro:
 	default:
 		return call(muncher)
 	}
@@ -158,7 +203,7 @@ func block(muncher *tokenMuncher) (Statement, error) {
 	}
 
 	// TODO(Matt): Eventually support params
-	var parent string
+	parents := make([]string, 0)
 	if token.Type == Macro {
 		if _, err := muncher.tryEat(LeftParen); err != nil {
 			return Statement{}, err
@@ -167,12 +212,24 @@ func block(muncher *tokenMuncher) (Statement, error) {
 			return Statement{}, err
 		}
 	} else if muncher.eatIf(Colon) {
-		parentIdent, err := muncher.tryEat(Identifier)
-		if err != nil {
-			return Statement{}, err
+		for {
+			parentIdent, err := muncher.tryEat(Identifier)
+			if err != nil {
+				return Statement{}, err
+			}
+
+			parents = append(parents, parentIdent.data.(string))
+
+			// Only blocks and styles may have more than one parent
+			if token.Type == Slide || token.Type == Master || !muncher.eatIf(Comma) {
+				break
+			}
 		}
+	}
 
-		parent = parentIdent.data.(string)
+	var parent string
+	if len(parents) > 0 {
+		parent = parents[0]
 	}
 
 	if _, err := muncher.tryEat(LeftBrace); err != nil {
@@ -201,11 +258,34 @@ func block(muncher *tokenMuncher) (Statement, error) {
 			parent:     parent,
 			statements: statements,
 		}
-	case Block:
+	case Master:
+		Type = MasterDecl
+		data = MasterDeclaration{
+			name:       identToken.data.(string),
+			parent:     parent,
+			statements: statements,
+		}
+	case Block, Code, Table, Diagram, Chart:
 		Type = BlockDecl
 		data = BlockDeclaration{
 			name:       identToken.data.(string),
-			parent:     parent,
+			kind:       token.Type,
+			parents:    parents,
+			statements: statements,
+		}
+	case Rect, Ellipse, Line, Arrow:
+		Type = ShapeDecl
+		data = ShapeDeclaration{
+			name:       identToken.data.(string),
+			kind:       token.Type,
+			parents:    parents,
+			statements: statements,
+		}
+	case Style:
+		Type = StyleDecl
+		data = StyleDeclaration{
+			name:       identToken.data.(string),
+			parents:    parents,
 			statements: statements,
 		}
 	case Macro:
@@ -274,6 +354,9 @@ func assignment(muncher *tokenMuncher) (Statement, error) {
 		if _, err := muncher.tryEat(Dot); err != nil {
 			return Statement{}, err
 		}
+	case AtSign:
+		ty = DirectiveAssignment
+		muncher.eat()
 	}
 
 	if !muncher.eatIf(Identifier) {
@@ -283,6 +366,11 @@ func assignment(muncher *tokenMuncher) (Statement, error) {
 
 	identToken := muncher.previous()
 
+	var argument string
+	if ty == DirectiveAssignment && muncher.eatIf(String) {
+		argument = muncher.previous().data.(string)
+	}
+
 	if _, err := muncher.tryEat(EqualSign); err != nil {
 		return Statement{}, err
 	}
@@ -310,6 +398,12 @@ func assignment(muncher *tokenMuncher) (Statement, error) {
 			name:  identToken.data.(string),
 			value: value,
 		}
+	case DirectiveAssignment:
+		data = DirectiveStatement{
+			name:     identToken.data.(string),
+			argument: argument,
+			value:    value,
+		}
 	}
 
 	if _, err := muncher.tryEat(Semicolon); err != nil {
@@ -328,7 +422,9 @@ func colorLiteral(muncher *tokenMuncher) (interface{}, error) {
 		values := []uint8{0, 0, 0, 255}
 
 		if value, err := muncher.tryEat(Integer); err == nil {
-			values[0] = value.data.(uint8)
+			if values[0], err = colorComponent(value); err != nil {
+				return nil, err
+			}
 		}
 
 		// We need to eat at least two more comma + ident pairs
@@ -343,15 +439,21 @@ func colorLiteral(muncher *tokenMuncher) (interface{}, error) {
 				return nil, err
 			}
 
-			values[i] = value.data.(uint8)
+			if values[i], err = colorComponent(value); err != nil {
+				return nil, err
+			}
 		}
 
 		// Allow trailing comma
 		if muncher.eatIf(Comma) {
 			// ... and fourth param if supplied
 			if muncher.eatIf(Integer) {
-				value := muncher.previous()
-				values[3] = value.data.(uint8)
+				value, err := colorComponent(muncher.previous())
+				if err != nil {
+					return nil, err
+				}
+
+				values[3] = value
 				// Get rid of any trailing comma
 				muncher.eatIf(Comma)
 			}
@@ -375,20 +477,69 @@ func colorLiteral(muncher *tokenMuncher) (interface{}, error) {
 func value(muncher *tokenMuncher) (interface{}, error) {
 	token := muncher.peek()
 
-	if token.Type == String {
+	// Text may be used as a string which spans lines
+	if token.Type == String || token.Type == Text {
 		muncher.eat()
 		return token.data.(string), nil
 	} else if token.Type == Integer {
 		muncher.eat()
-		return token.data.(uint8), nil
+		return token.data.(uint), nil
+	} else if token.Type == Decimal {
+		muncher.eat()
+		return token.data.(float64), nil
+	} else if token.Type == Boolean {
+		muncher.eat()
+		return token.data.(bool), nil
+	} else if token.Type == HexColor {
+		muncher.eat()
+		return token.data.(ColorLiteral), nil
 	} else if token.Type == Identifier {
 		muncher.eat()
+
+		if muncher.eatIf(LeftParen) {
+			return functionCall(muncher, token)
+		}
+
 		return VariableReference{reference: token.data.(string)}, nil
 	}
 
 	return nil, tokenErrorInfo(token, parsing, "Expected value")
 }
 
+func functionCall(muncher *tokenMuncher, identToken Token) (interface{}, error) {
+	arguments := make([]interface{}, 0)
+
+	for !muncher.eatIf(RightParen) {
+		if len(arguments) > 0 {
+			if _, err := muncher.tryEat(Comma); err != nil {
+				return nil, err
+			}
+		}
+
+		argument, err := colorLiteral(muncher)
+		if err != nil {
+			return nil, err
+		}
+
+		arguments = append(arguments, argument)
+	}
+
+	return FunctionCall{
+		token:     identToken,
+		name:      identToken.data.(string),
+		arguments: arguments,
+	}, nil
+}
+
+func colorComponent(token Token) (uint8, error) {
+	component := token.data.(uint)
+	if component > 255 {
+		return 0, tokenErrorInfo(token, parsing, "Color components must be between 0 and 255")
+	}
+
+	return uint8(component), nil
+}
+
 func synchronizeFromErrorState(muncher *tokenMuncher) {
 	muncher.eat()
 
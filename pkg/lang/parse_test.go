@@ -68,21 +68,21 @@ func TestColorLiteral(t *testing.T) {
 		},
 		{
 			Type: Integer,
-			data: uint8(12),
+			data: uint(12),
 		},
 		{
 			Type: Comma,
 		},
 		{
 			Type: Integer,
-			data: uint8(10),
+			data: uint(10),
 		},
 		{
 			Type: Comma,
 		},
 		{
 			Type: Integer,
-			data: uint8(93),
+			data: uint(93),
 		},
 		{
 			Type: Comma,
@@ -125,3 +125,77 @@ func TestColorLiteral(t *testing.T) {
 		return
 	}
 }
+
+func TestFunctionCall(t *testing.T) {
+	tokens := []Token{
+		{
+			Type: Let,
+		},
+		{
+			Type: Identifier,
+			data: "color",
+		},
+		{
+			Type: EqualSign,
+		},
+		{
+			Type: Identifier,
+			data: "hsl",
+		},
+		{
+			Type: LeftParen,
+		},
+		{
+			Type: Integer,
+			data: uint(200),
+		},
+		{
+			Type: Comma,
+		},
+		{
+			Type: Identifier,
+			data: "saturation",
+		},
+		{
+			Type: Comma,
+		},
+		{
+			Type: Integer,
+			data: uint(40),
+		},
+		{
+			Type: RightParen,
+		},
+		{
+			Type: Semicolon,
+		},
+	}
+
+	statements, err := parser.Parse(tokens)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(statements) != 1 {
+		t.Errorf("Expected exactly one statement-- got %d", len(statements))
+		return
+	}
+
+	data := statements[0].data.(VariableDeclStatement)
+	call, ok := data.value.(FunctionCall)
+	if !ok {
+		t.Errorf("Expected FunctionCall-- got %v", data.value)
+		return
+	}
+
+	if call.name != "hsl" || len(call.arguments) != 3 {
+		t.Errorf("Expected hsl with three arguments-- got %s with %d", call.name, len(call.arguments))
+		return
+	}
+
+	if reference, ok := call.arguments[1].(VariableReference); !ok || reference.reference != "saturation" {
+		t.Errorf("Expected reference to saturation-- got %v", call.arguments[1])
+	}
+}
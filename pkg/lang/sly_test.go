@@ -2,6 +2,9 @@ package lang
 
 import (
 	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -163,3 +166,1263 @@ func TestMacro(t *testing.T) {
 		t.Errorf("Expected blue font color-- got (%d, %d, %d, %d)", fontColor.R, fontColor.G, fontColor.B, fontColor.A)
 	}
 }
+
+func TestShowDimensions(t *testing.T) {
+	cases := []struct {
+		source string
+		width  uint
+		height uint
+	}{
+		{source: ``, width: 1280, height: 720},
+		{source: `@aspectRatio = "4:3";`, width: 1280, height: 960},
+		{source: `@aspectRatio = "4:3"; @height = 600;`, width: 800, height: 600},
+		{source: `@width = 1920;`, width: 1920, height: 1080},
+		{source: `let w = 1024; @width = w; @height = 768; @aspectRatio = "16:9";`, width: 1024, height: 768},
+	}
+
+	for _, c := range cases {
+		show, err := sly.ReadSlideShowString(c.source)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if show.Dimensions.Width != c.width || show.Dimensions.Height != c.height {
+			t.Errorf("Expected %dx%d for `%s`-- got %dx%d", c.width, c.height, c.source, show.Dimensions.Width, show.Dimensions.Height)
+		}
+	}
+}
+
+func TestDegenerateAspectRatio(t *testing.T) {
+	sources := []string{
+		`@aspectRatio = "100000:1";`,
+		`@aspectRatio = "1:100000"; @height = 720;`,
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for `%s`, which leaves a dimension of zero", source)
+		}
+	}
+}
+
+func TestOversizedDimensions(t *testing.T) {
+	sources := []string{
+		`@width = 100000; @height = 100000;`,
+		`@height = 8192;`,
+		`@aspectRatio = "1:100";`,
+		`@width = 8000; @aspectRatio = "1:2";`,
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for `%s`, which makes the show too large", source)
+		}
+	}
+
+	if _, err := sly.ReadSlideShowString(`@width = 8192; @height = 8192;`); err != nil {
+		t.Errorf("Expected the largest show to be allowed-- got %s", err)
+	}
+}
+
+func TestDirectiveOutsideFileScope(t *testing.T) {
+	source := `
+	slide first {
+		@aspectRatio = "4:3";
+	}`
+
+	if _, err := sly.ReadSlideShowString(source); err == nil {
+		t.Error("Expected an error for a directive within a slide")
+	}
+}
+
+func TestShowMetadata(t *testing.T) {
+	source := `
+	let author = "Ada Lovelace";
+	@title = "Quarterly Review";
+	@author = author;
+	@date = "2020-06-01";
+	@description = "Numbers, mostly";
+	@lang = "en";`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := types.Metadata{
+		Title:       "Quarterly Review",
+		Author:      "Ada Lovelace",
+		Date:        "2020-06-01",
+		Description: "Numbers, mostly",
+		Language:    "en",
+	}
+	if show.Metadata != expected {
+		t.Errorf("Expected metadata %+v-- got %+v", expected, show.Metadata)
+	}
+}
+
+func TestSlideDecorations(t *testing.T) {
+	source := `
+	@header = "ACME Corp";
+	@footer = "Confidential";
+	@slideNumbers = true;
+	@decorateTitleSlide = false;
+
+	slide title {}
+
+	slide quiet {
+		self.header = "";
+		self.slideNumber = false;
+	}
+
+	slide inherited : quiet {}
+
+	slide plain {}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := []types.Slide{
+		{},
+		{Footer: "Confidential"},
+		{Footer: "Confidential"},
+		{Header: "ACME Corp", Footer: "Confidential", Number: "4 / 4"},
+	}
+
+	for i, slide := range show.Slides {
+		if slide.Header != expected[i].Header || slide.Footer != expected[i].Footer || slide.Number != expected[i].Number {
+			t.Errorf(
+				"Expected slide %d to have (%q, %q, %q)-- got (%q, %q, %q)",
+				i,
+				expected[i].Header, expected[i].Footer, expected[i].Number,
+				slide.Header, slide.Footer, slide.Number,
+			)
+		}
+	}
+}
+
+func TestMasterSlides(t *testing.T) {
+	source := `
+	master base {
+		self.backgroundColor = "black";
+		self.footer = "ACME";
+
+		block title {
+			self.fontSize = 42;
+			self.x = 5;
+			self.y = 5;
+			self.width = 90;
+		}
+
+		block body {
+			self.fontSize = 24;
+		}
+
+		block legal {
+			---(c) ACME---
+		}
+	}
+
+	master wide : base {
+		block body {
+			self.justify = "center";
+		}
+	}
+
+	slide first : wide {
+		block aside {
+			---Aside---
+		}
+
+		block title {
+			---Hello---
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(show.Slides) != 1 {
+		t.Errorf("Expected masters to be omitted from the show-- got %d slides", len(show.Slides))
+		return
+	}
+
+	slide := show.Slides[0]
+	if slide.Background != color.Black {
+		t.Errorf("Expected black background-- got %v", slide.Background)
+		return
+	} else if slide.Footer != "ACME" {
+		t.Errorf("Expected footer \"ACME\"-- got %q", slide.Footer)
+		return
+	} else if len(slide.Blocks) != 3 {
+		t.Errorf("Expected exactly three blocks-- got %d", len(slide.Blocks))
+		return
+	}
+
+	title := slide.Blocks[0]
+	if title.Words != "Hello" || title.Style.Size != 42 {
+		t.Errorf("Expected title placeholder to be filled-- got %q at size %d", title.Words, title.Style.Size)
+		return
+	} else if title.Frame != (types.Frame{X: 5, Y: 5, Width: 90}) {
+		t.Errorf("Expected title placeholder position-- got %+v", title.Frame)
+		return
+	}
+
+	if slide.Blocks[1].Words != "(c) ACME" {
+		t.Errorf("Expected unfilled placeholder with text-- got %q", slide.Blocks[1].Words)
+		return
+	} else if slide.Blocks[2].Words != "Aside" {
+		t.Errorf("Expected non-placeholder block last-- got %q", slide.Blocks[2].Words)
+	}
+}
+
+func TestBlockPositions(t *testing.T) {
+	source := `
+	slide first {
+		block corner {
+			self.x = 0;
+			self.y = 0;
+			---Corner---
+		}
+
+		block flowed {
+			---Flowed---
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	blocks := show.Slides[0].Blocks
+	if !blocks[0].Positioned || !blocks[0].Frame.IsZero() {
+		t.Errorf("Expected a block positioned at the top left corner-- got %+v", blocks[0].Frame)
+		return
+	} else if blocks[1].Positioned {
+		t.Errorf("Expected a block without a position to be laid out in turn")
+	}
+}
+
+func TestStyles(t *testing.T) {
+	source := `
+	style base {
+		self.font = "Fira Code";
+		self.fontSize = 24;
+	}
+
+	style heading : base, emphasis {
+		self.fontSize = 42;
+	}
+
+	slide first {
+		block title : heading {
+			---Hello---
+		}
+
+		block body {
+			self.justify = "right";
+			self.apply = "base";
+			---World---
+		}
+	}
+
+	# Styles may be declared after their first use
+	style emphasis {
+		self.fontColor = "red";
+		self.justify = "center";
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	title := show.Slides[0].Blocks[0]
+	if title.Style.Font != "Fira Code" || title.Style.Size != 42 || title.Style.Justification != types.Center {
+		t.Errorf("Expected Fira Code at size 42, centered-- got %s at size %d, %s", title.Style.Font, title.Style.Size, title.Style.Justification)
+		return
+	} else if title.Style.Color != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("Expected red font color-- got %v", title.Style.Color)
+		return
+	}
+
+	body := show.Slides[0].Blocks[1]
+	if body.Style.Font != "Fira Code" || body.Style.Size != 24 || body.Style.Justification != types.Right {
+		t.Errorf("Expected Fira Code at size 24, right justified-- got %s at size %d, %s", body.Style.Font, body.Style.Size, body.Style.Justification)
+	}
+}
+
+func TestStyleCycle(t *testing.T) {
+	source := `
+	style a : b {}
+	style b : c {}
+	style c : a {}
+
+	slide first {
+		block title : a {}
+	}`
+
+	_, err := sly.ReadSlideShowString(source)
+	if err == nil {
+		t.Error("Expected an error for cyclic styles")
+		return
+	}
+
+	if !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("Expected the cycle to be described-- got %s", err)
+	}
+}
+
+func TestStyleApplyCycle(t *testing.T) {
+	cases := []struct {
+		source string
+		cycle  string
+	}{
+		{
+			source: `style a { self.apply = "a"; } slide s { block x : a { ---hi--- } }`,
+			cycle:  "a -> a",
+		},
+		{
+			source: `style a { self.apply = "b"; } style b { self.apply = "a"; } slide s { block x { self.apply = "a"; } }`,
+			cycle:  "a -> b -> a",
+		},
+	}
+
+	for _, c := range cases {
+		_, err := sly.ReadSlideShowString(c.source)
+		if err == nil {
+			t.Errorf("Expected an error for `%s`", c.source)
+			return
+		}
+
+		if !strings.Contains(err.Error(), c.cycle) {
+			t.Errorf("Expected the cycle %s to be described-- got %s", c.cycle, err)
+		}
+	}
+}
+
+func TestBuiltinTheme(t *testing.T) {
+	source := `
+	@theme = "dark";
+
+	# Files may redeclare anything their theme provides
+	let accent = (255, 0, 0);
+
+	slide intro : title {
+		block title {
+			---Welcome!---
+		}
+	}
+
+	slide plain {
+		block body {
+			---Just text---
+		}
+
+		block callout : heading {
+			---Look here---
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dark := color.RGBA{R: 30, G: 30, B: 30, A: 255}
+	for i, slide := range show.Slides {
+		if slide.Background != dark {
+			t.Errorf("Expected slide %d to have the theme's background-- got %v", i, slide.Background)
+			return
+		}
+	}
+
+	title := show.Slides[0].Blocks[0]
+	if title.Style.Size != 48 || title.Style.Justification != types.Center || title.Frame.Y != 35 {
+		t.Errorf("Expected the title placeholder's style-- got %+v at %+v", title.Style, title.Frame)
+		return
+	}
+
+	body := show.Slides[1].Blocks[0]
+	if body.Style.Font != "Helvetica" || body.Style.Size != 28 {
+		t.Errorf("Expected the theme's default style-- got %s at size %d", body.Style.Font, body.Style.Size)
+		return
+	}
+
+	// Styles look up variables where they are applied
+	callout := show.Slides[1].Blocks[1]
+	if callout.Style.Color != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("Expected the redeclared accent color-- got %v", callout.Style.Color)
+	}
+}
+
+func TestThemeFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slydes")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	themeSource := `
+	style default {
+		self.font = "Comic Sans";
+	}
+	`
+	if err := ioutil.WriteFile(filepath.Join(dir, "fun.sly"), []byte(themeSource), 0644); err != nil {
+		t.Error(err)
+		return
+	}
+
+	themed := NewSly()
+	themed.Compiler = DefaultCompiler{Theme: "fun.sly", BaseDir: dir}
+
+	// The compiler's theme takes precedence over the file's
+	source := `
+	@theme = "light";
+
+	slide first {
+		block body {
+			---Hello---
+		}
+	}`
+
+	show, err := themed.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if font := show.Slides[0].Blocks[0].Style.Font; font != "Comic Sans" {
+		t.Errorf("Expected Comic Sans font-- got %s", font)
+	} else if background := show.Slides[0].Background; background != color.White {
+		t.Errorf("Expected white background-- got %v", background)
+	}
+}
+
+func TestRedeclaredThemeStyle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slydes")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	// The theme's master applies callout, flattening it with the
+	// theme's accent before the file redeclares accent
+	themeSource := `
+	style accent {
+		self.fontSize = 10;
+	}
+
+	style callout : accent {}
+
+	master boxed {
+		block aside : callout {}
+	}
+	`
+	if err := ioutil.WriteFile(filepath.Join(dir, "accented.sly"), []byte(themeSource), 0644); err != nil {
+		t.Error(err)
+		return
+	}
+
+	themed := NewSly()
+	themed.Compiler = DefaultCompiler{Theme: "accented.sly", BaseDir: dir}
+
+	source := `
+	style accent {
+		self.fontSize = 30;
+	}
+
+	slide first {
+		block note : callout {
+			---Hello---
+		}
+	}`
+
+	show, err := themed.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if size := show.Slides[0].Blocks[0].Style.Size; size != 30 {
+		t.Errorf("Expected callout to inherit the redeclared accent at size 30-- got %d", size)
+	}
+}
+
+func TestColorValues(t *testing.T) {
+	source := `
+	let translucent = #ff000080;
+
+	slide first {
+		self.backgroundColor = hsl(120, 100, 25);
+
+		block named {
+			self.fontColor = "RebeccaPurple";
+			---Named---
+		}
+
+		block hex {
+			self.fontColor = translucent;
+			---Hex---
+		}
+
+		block green {
+			self.fontColor = "green";
+			---Green---
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	slide := show.Slides[0]
+	if slide.Background != (color.RGBA{R: 0, G: 128, B: 0, A: 255}) {
+		t.Errorf("Expected (0, 128, 0, 255) background-- got %v", slide.Background)
+		return
+	}
+
+	if named := slide.Blocks[0].Style.Color; named != (color.RGBA{R: 102, G: 51, B: 153, A: 255}) {
+		t.Errorf("Expected rebeccapurple font color-- got %v", named)
+		return
+	}
+
+	// Translucent colors must not be premultiplied
+	if hex := slide.Blocks[1].Style.Color; hex != (color.NRGBA{R: 255, G: 0, B: 0, A: 128}) {
+		t.Errorf("Expected (255, 0, 0, 128) font color-- got %v", hex)
+		return
+	}
+
+	if green := slide.Blocks[2].Style.Color; green != (color.RGBA{R: 0, G: 255, B: 0, A: 255}) {
+		t.Errorf("Expected green to stay (0, 255, 0, 255)-- got %v", green)
+	}
+}
+
+func TestColorFunctions(t *testing.T) {
+	source := `
+	let brand = "red";
+
+	slide first {
+		self.backgroundColor = mix("black", "white", 0.5);
+
+		block lightened {
+			self.fontColor = lighten("black", 0.2);
+			---Lightened---
+		}
+
+		block darkened {
+			self.fontColor = darken("white", 0.5);
+			---Darkened---
+		}
+
+		block translucent {
+			self.fontColor = withAlpha(brand, 0.5);
+			---Translucent---
+		}
+
+		block complemented {
+			self.fontColor = complement(brand);
+			---Complemented---
+		}
+
+		block faded {
+			self.fontColor = hsl(0, 100, 50, 0.5);
+			---Faded---
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	slide := show.Slides[0]
+	if slide.Background != (color.RGBA{R: 128, G: 128, B: 128, A: 255}) {
+		t.Errorf("Expected (128, 128, 128, 255) background-- got %v", slide.Background)
+		return
+	}
+
+	expected := []color.Color{
+		color.RGBA{R: 51, G: 51, B: 51, A: 255},
+		color.RGBA{R: 128, G: 128, B: 128, A: 255},
+		color.NRGBA{R: 255, G: 0, B: 0, A: 128},
+		color.RGBA{R: 0, G: 255, B: 255, A: 255},
+		color.NRGBA{R: 255, G: 0, B: 0, A: 128},
+	}
+
+	for i, block := range slide.Blocks {
+		if block.Style.Color != expected[i] {
+			t.Errorf("Expected %v font color for %s-- got %v", expected[i], block.Words, block.Style.Color)
+			return
+		}
+	}
+}
+
+func TestColorFunctionErrors(t *testing.T) {
+	sources := []string{
+		`slide first { self.backgroundColor = lighten("red", 20); }`,
+		`slide first { self.backgroundColor = hsl(0, 100, 50, 128); }`,
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for an out of range amount in `%s`", source)
+		}
+	}
+}
+
+func TestBackgroundLayers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slydes")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "photo.png"), []byte{}, 0644); err != nil {
+		t.Error(err)
+		return
+	}
+
+	withImages := NewSly()
+	withImages.Compiler = DefaultCompiler{BaseDir: dir}
+
+	source := `
+	master photo {
+		self.backgroundFit = "tile";
+		self.backgroundGradient = linearGradient(90, "black", "white");
+	}
+
+	slide first : photo {
+		self.backgroundImage = "photo.png";
+	}
+
+	slide second : first {
+		self.backgroundFit = "cover";
+		self.backgroundGradient = radialGradient("red", "blue");
+	}`
+
+	show, err := withImages.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	first := show.Slides[0]
+	if gradient := first.BackgroundGradient; gradient == nil || gradient.Kind != types.LinearGradient || gradient.Angle != 90 {
+		t.Errorf("Expected a 90 degree linear gradient-- got %v", gradient)
+		return
+	}
+
+	if image := first.BackgroundImage; image == nil || image.Path != filepath.Join(dir, "photo.png") || image.Fit != types.Tile {
+		t.Errorf("Expected a tiled photo.png-- got %v", image)
+		return
+	}
+
+	// Children must not change the images of their parents
+	second := show.Slides[1]
+	if second.BackgroundImage.Fit != types.Cover || first.BackgroundImage.Fit != types.Tile {
+		t.Errorf("Expected the second slide alone to cover-- got %v and %v", first.BackgroundImage.Fit, second.BackgroundImage.Fit)
+		return
+	}
+
+	if gradient := second.BackgroundGradient; gradient.Kind != types.RadialGradient || len(gradient.Stops) != 2 {
+		t.Errorf("Expected a radial gradient with two stops-- got %v", gradient)
+	}
+}
+
+func TestMissingBackgroundImage(t *testing.T) {
+	source := `
+	slide first {
+		self.backgroundImage = "does-not-exist.png";
+	}`
+
+	if _, err := sly.ReadSlideShowString(source); err == nil {
+		t.Errorf("Expected an error for a missing background image")
+	}
+}
+
+func TestTypography(t *testing.T) {
+	source := `
+	style emphasis {
+		self.fontWeight = "bold";
+		self.italic = true;
+		self.underline = true;
+	}
+
+	slide first {
+		block title : emphasis {
+			self.strikethrough = true;
+			self.letterSpacing = 1.5;
+			self.lineHeight = 1.2;
+			self.textTransform = "uppercase";
+			self.verticalAlign = "middle";
+			self.padding = 16;
+			---Title---
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	style := show.Slides[0].Blocks[0].Style
+	if style.Weight != 700 || !style.Italic || !style.Underline || !style.Strikethrough {
+		t.Errorf("Expected bold, italic, underlined and struck through text-- got %+v", style)
+		return
+	}
+
+	if style.LetterSpacing != 1.5 || style.LineHeight != 1.2 || style.Padding != 16 {
+		t.Errorf("Expected spacing of 1.5, line height of 1.2 and padding of 16-- got %+v", style)
+		return
+	}
+
+	if style.Transform != types.Uppercase || style.VerticalAlignment != types.Middle {
+		t.Errorf("Expected uppercase text in the middle-- got %s and %s", style.Transform, style.VerticalAlignment)
+	}
+}
+
+func TestInvalidTypography(t *testing.T) {
+	sources := []string{
+		`slide first { block a { self.fontWeight = 1200; } }`,
+		`slide first { block a { self.italic = "yes"; } }`,
+		`slide first { block a { self.verticalAlign = "center"; } }`,
+		`slide first { self.padding = 4; }`,
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestFontStacks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slydes")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "brand.woff2"), []byte{}, 0644); err != nil {
+		t.Error(err)
+		return
+	}
+
+	withFonts := NewSly()
+	withFonts.Compiler = DefaultCompiler{BaseDir: dir}
+
+	source := `
+	@font "Brand" = "brand.woff2";
+
+	style branded {
+		self.font = fontStack("Brand", "Helvetica Neue", "sans-serif");
+	}
+
+	slide first {
+		block stacked : branded {
+			---Stacked---
+		}
+
+		block replaced : branded {
+			self.font = "Georgia";
+			---Replaced---
+		}
+	}`
+
+	show, err := withFonts.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(show.Fonts) != 1 || show.Fonts[0].Family != "Brand" || show.Fonts[0].Path != filepath.Join(dir, "brand.woff2") {
+		t.Errorf("Expected the Brand font to be embedded-- got %v", show.Fonts)
+		return
+	}
+
+	stack := show.Slides[0].Blocks[0].Style.FontStack()
+	if len(stack) != 3 || stack[0] != "Brand" || stack[2] != "sans-serif" {
+		t.Errorf("Expected Brand, Helvetica Neue, sans-serif-- got %v", stack)
+		return
+	}
+
+	// Setting a single font replaces the whole stack
+	if stack := show.Slides[0].Blocks[1].Style.FontStack(); len(stack) != 1 || stack[0] != "Georgia" {
+		t.Errorf("Expected only Georgia-- got %v", stack)
+	}
+}
+
+func TestInvalidFontDirective(t *testing.T) {
+	sources := []string{
+		`@font = "brand.woff2";`,
+		`@font "Brand" = "brand.exe";`,
+		`@font "Brand" = "missing.woff2";`,
+		`@title "Brand" = "Hello";`,
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestCodeBlocks(t *testing.T) {
+	source := `
+	slide first {
+		code snippet {
+			self.language = "go";
+			self.lineNumbers = true;
+			self.highlight = "1, 3-4";
+			self.codeTheme = "dark";
+			---
+			func main() {
+				fmt.Println("hi")
+			}
+			---
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	block := show.Slides[0].Blocks[0]
+	if block.Code == nil {
+		t.Errorf("Expected a code block")
+		return
+	}
+
+	// The indentation shared by every line is removed, but the rest is kept as is
+	expected := "func main() {\n\tfmt.Println(\"hi\")\n}"
+	if block.Words != expected {
+		t.Errorf("Expected %q-- got %q", expected, block.Words)
+		return
+	}
+
+	code := block.Code
+	if code.Language != "go" || !code.LineNumbers || code.Theme != types.DarkCode {
+		t.Errorf("Expected dark, numbered Go code-- got %+v", code)
+		return
+	}
+
+	if !code.IsHighlighted(1) || code.IsHighlighted(2) || !code.IsHighlighted(4) {
+		t.Errorf("Expected lines 1, 3 and 4 to be highlighted-- got %v", code.Highlights)
+		return
+	}
+
+	if block.Style.Font != "Menlo" {
+		t.Errorf("Expected a monospaced font-- got %s", block.Style.Font)
+	}
+}
+
+func TestInvalidCodeBlocks(t *testing.T) {
+	sources := []string{
+		`slide first { block a { self.language = "go"; } }`,
+		`slide first { code a { self.language = "cobol"; } }`,
+		`slide first { code a { self.highlight = "4-2"; } }`,
+		`slide first { code a { self.highlight = 0; } }`,
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestTables(t *testing.T) {
+	source := `
+	slide first {
+		table results {
+			self.columnAlign = "left, right";
+			self.border = 2;
+			self.headerBackground = "lightgray";
+			---
+			Quarter, Revenue, Note
+			Q1, 1.2M, "Up, barely"
+			Q2, 2.0M
+			---
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	table := show.Slides[0].Blocks[0].Table
+	if table == nil {
+		t.Errorf("Expected a table")
+		return
+	}
+
+	if len(table.Rows) != 3 || len(table.Rows[2]) != 3 {
+		t.Errorf("Expected three rows of three cells-- got %v", table.Rows)
+		return
+	}
+
+	if cell := table.Rows[1][2]; cell != "Up, barely" {
+		t.Errorf("Expected a quoted cell to keep its comma-- got %q", cell)
+		return
+	}
+
+	if !table.HeaderRow || table.Border != 2 || table.ColumnAlignment(1, types.Left) != types.Right || table.ColumnAlignment(2, types.Center) != types.Center {
+		t.Errorf("Expected a header row, 2px border and right aligned second column-- got %+v", table)
+	}
+}
+
+func TestInvalidTables(t *testing.T) {
+	sources := []string{
+		`slide first { block a { self.border = 1; } }`,
+		`slide first { table a { self.columnAlign = "left, middle"; } }`,
+		"slide first { table a { ---\"unterminated--- } }",
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestShapes(t *testing.T) {
+	source := `
+	master boxed {
+		rect frame {
+			self.width = 100;
+			self.height = 100;
+		}
+	}
+
+	slide first : boxed {
+		rect highlight {
+			self.x = 10;
+			self.y = 20;
+			self.width = 30;
+			self.height = 40;
+			self.fill = "gold";
+			self.stroke = "none";
+		}
+
+		ellipse spot : highlight {
+			self.width = 5;
+			self.height = 5;
+		}
+
+		arrow pointer {
+			self.x1 = 50;
+			self.y1 = 50;
+			self.x2 = 40;
+			self.y2 = 30;
+			self.strokeWidth = 4;
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	shapes := show.Slides[0].Shapes
+	if len(shapes) != 4 {
+		t.Errorf("Expected the master's shape and three of the slide's own-- got %d", len(shapes))
+		return
+	}
+
+	highlight := shapes[1]
+	if highlight.Kind != types.Rectangle || highlight.Frame != (types.Frame{X: 10, Y: 20, Width: 30, Height: 40}) || highlight.Stroke != nil {
+		t.Errorf("Expected an unstroked rectangle at (10, 20)-- got %+v", highlight)
+		return
+	}
+
+	if spot := shapes[2]; spot.Kind != types.Ellipse || spot.Fill != highlight.Fill || spot.Frame.X != 0 {
+		t.Errorf("Expected an ellipse with the highlight's colors but not its position-- got %+v", spot)
+		return
+	}
+
+	if pointer := shapes[3]; pointer.Kind != types.Arrow || pointer.From != (types.Point{X: 50, Y: 50}) || pointer.To != (types.Point{X: 40, Y: 30}) || pointer.StrokeWidth != 4 {
+		t.Errorf("Expected an arrow from (50, 50) to (40, 30)-- got %+v", pointer)
+	}
+}
+
+func TestInvalidShapes(t *testing.T) {
+	sources := []string{
+		`rect box { self.x = 1; }`,
+		`slide first { line l { self.width = 10; } }`,
+		`slide first { rect r { self.x1 = 10; } }`,
+		`slide first { arrow a { self.fill = "red"; } }`,
+		`slide first { rect r { self.fontSize = 10; } }`,
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestDiagrams(t *testing.T) {
+	source := `
+	slide first {
+		diagram request {
+			self.diagramType = "flowchart";
+			self.direction = "right";
+			---
+			web: Web browser
+			web -> api: GET /slides
+			api --> web
+			---
+		}
+
+		diagram calls {
+			self.diagramType = "sequence";
+			---a -> b---
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	diagram := show.Slides[0].Blocks[0].Diagram
+	if diagram == nil {
+		t.Errorf("Expected a diagram")
+		return
+	}
+
+	if diagram.Kind != types.Flowchart || diagram.Direction != types.LeftToRight {
+		t.Errorf("Expected a left to right flowchart-- got %+v", diagram)
+		return
+	}
+
+	if len(diagram.Nodes) != 2 || diagram.Nodes[0].Label != "Web browser" {
+		t.Errorf("Expected two nodes with a labelled browser-- got %+v", diagram.Nodes)
+		return
+	}
+
+	if len(diagram.Edges) != 2 || diagram.Edges[0].Label != "GET /slides" || !diagram.Edges[1].Dashed {
+		t.Errorf("Expected a labelled edge and a dashed edge-- got %+v", diagram.Edges)
+		return
+	}
+
+	if kind := show.Slides[0].Blocks[1].Diagram.Kind; kind != types.Sequence {
+		t.Errorf("Expected a sequence diagram-- got %v", kind)
+	}
+}
+
+func TestInvalidDiagrams(t *testing.T) {
+	sources := []string{
+		`slide first { block a { self.diagramType = "sequence"; } }`,
+		`slide first { diagram a { self.diagramType = "venn"; } }`,
+		`slide first { diagram a { self.direction = "up"; } }`,
+		"slide first { diagram a { ---a -> --- } }",
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestMath(t *testing.T) {
+	source := `
+	slide first {
+		block formula {
+			---
+			Euler's identity, $e^{i\pi} + 1 = 0$, costs \$0
+			---
+		}
+	}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The formula is kept as written for each renderer to convert
+	if words := show.Slides[0].Blocks[0].Words; !strings.Contains(words, `$e^{i\pi} + 1 = 0$`) {
+		t.Errorf("Expected the formula to be left in the text-- got %q", words)
+	}
+}
+
+func TestInvalidMath(t *testing.T) {
+	sources := []string{
+		`slide first { block a { ---$\frac{1}$--- } }`,
+		`slide first { block a { ---$$\unknown$$--- } }`,
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestCharts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slydes")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	data := "Month,Visitors\nJan,120\nFeb,95.5\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "visitors.csv"), []byte(data), 0644); err != nil {
+		t.Error(err)
+		return
+	}
+
+	withData := NewSly()
+	withData.Compiler = DefaultCompiler{BaseDir: dir}
+
+	source := `
+	@theme = "light";
+
+	slide first {
+		chart revenue {
+			self.xLabel = "Quarter";
+			---
+			Quarter, 2023, 2024
+			Q1, 1.2, 1.5
+			Q2, 2, -0.5
+			---
+		}
+
+		chart visitors {
+			self.chartType = "pie";
+			self.data = "visitors.csv";
+			self.colors = palette("red", "blue");
+		}
+	}`
+
+	show, err := withData.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	revenue := show.Slides[0].Blocks[0].Chart
+	if revenue == nil || revenue.Kind != types.BarChart || revenue.XLabel != "Quarter" {
+		t.Errorf("Expected a bar chart labelled by quarter-- got %+v", revenue)
+		return
+	}
+
+	if len(revenue.Series) != 2 || revenue.Series[1].Name != "2024" || revenue.Series[1].Values[1] != -0.5 {
+		t.Errorf("Expected two series of values-- got %+v", revenue.Series)
+		return
+	}
+
+	// Charts start with the theme's palette
+	if r, g, b, _ := revenue.Color(0).RGBA(); len(revenue.Colors) != 5 || r>>8 != 0 || g>>8 != 102 || b>>8 != 204 {
+		t.Errorf("Expected the light theme's chart colors-- got %v", revenue.Colors)
+		return
+	}
+
+	visitors := show.Slides[0].Blocks[1].Chart
+	if visitors.Kind != types.PieChart || len(visitors.Categories) != 2 || visitors.Series[0].Values[1] != 95.5 {
+		t.Errorf("Expected a pie chart read from a file-- got %+v", visitors)
+		return
+	}
+
+	if len(visitors.Colors) != 2 || visitors.Color(2) != visitors.Color(0) {
+		t.Errorf("Expected two colors used in turn-- got %v", visitors.Colors)
+	}
+}
+
+func TestInvalidCharts(t *testing.T) {
+	sources := []string{
+		`slide first { block a { self.chartType = "bar"; } }`,
+		`slide first { chart a { self.chartType = "donut"; } }`,
+		`slide first { chart a { self.data = "missing.csv"; } }`,
+		`slide first { chart a { self.colors = palette(); } }`,
+		`slide first { chart a { ---Month, Visitors--- } }`,
+		"slide first { chart a { ---\nMonth, Visitors\nJan, many\n--- } }",
+		"slide first { chart a { ---\nMonth, Visitors\nJan, NaN\n--- } }",
+		"slide first { chart a { ---\nMonth, Visitors\nJan, -Inf\n--- } }",
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestNotes(t *testing.T) {
+	source := `
+	slide first {
+		self.notes = ---
+			Mention the roadmap
+			---;
+	}
+
+	slide second {
+		self.notes = "Take questions";
+	}
+
+	slide third {}`
+
+	show, err := sly.ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if notes := show.Slides[0].Notes; notes != "Mention the roadmap" {
+		t.Errorf("Expected the notes to be trimmed-- got %q", notes)
+		return
+	}
+
+	if notes := show.Slides[1].Notes; notes != "Take questions" {
+		t.Errorf("Expected Take questions-- got %q", notes)
+		return
+	}
+
+	// Unlike styles, notes don't carry over to the next slide
+	if notes := show.Slides[2].Notes; notes != "" {
+		t.Errorf("Expected no notes-- got %q", notes)
+	}
+}
+
+func TestInvalidNotes(t *testing.T) {
+	sources := []string{
+		`slide first { block a { self.notes = "x"; } }`,
+		`slide first { self.notes = 1; }`,
+	}
+
+	for _, source := range sources {
+		if _, err := sly.ReadSlideShowString(source); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package lang
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Themes and files may declare a style and a master with this name to
+// provide the starting point for every block and slide, respectively
+const defaultName = "default"
+
+// Themes and files may declare a palette with this name to
+// provide the starting colors for every chart
+const chartColorsName = "chartColors"
+
+// A theme is a Sly file which bundles up variables, styles and masters.
+// It is compiled before the file using it, so that anything it declares
+// is available to (and may be redeclared by) that file
+type theme struct {
+	name       string
+	statements []Statement
+}
+
+var builtinThemes = map[string]string{
+	"light":         lightTheme,
+	"dark":          darkTheme,
+	"high-contrast": highContrastTheme,
+}
+
+// IsBuiltinTheme reports whether a theme name refers to one
+// of Sly's own themes rather than to a theme file
+func IsBuiltinTheme(name string) bool {
+	_, ok := builtinThemes[name]
+	return ok
+}
+
+// Load either a built-in theme or a theme file,
+// which is resolved relative to baseDir
+func loadTheme(name string, baseDir string) (theme, error) {
+	var reader io.Reader
+	if source, ok := builtinThemes[name]; ok {
+		reader = strings.NewReader(source)
+	} else {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return theme{}, fmt.Errorf("unable to load theme %q: %w", name, err)
+		}
+		defer file.Close()
+
+		reader = file
+	}
+
+	sly := NewSly()
+	tokens, err := sly.Lexer.Lex(reader)
+	if err != nil {
+		return theme{}, fmt.Errorf("unable to load theme %q:\n%w", name, err)
+	}
+
+	statements, err := sly.Parser.Parse(tokens)
+	if err != nil {
+		return theme{}, fmt.Errorf("unable to load theme %q:\n%w", name, err)
+	}
+
+	if err := checkTheme(statements); err != nil {
+		return theme{}, fmt.Errorf("unable to load theme %q:\n%w", name, err)
+	}
+
+	return theme{name: name, statements: statements}, nil
+}
+
+func checkTheme(statements []Statement) error {
+	errBundle := newErrorInfoBundle()
+
+	for _, statement := range statements {
+		switch statement.Type {
+		case SlideDecl:
+			errBundle.Add(tokenErrorInfo(statement.token, compilation, "A theme may not contain slides"))
+		case DirectiveAssignment:
+			if statement.data.(DirectiveStatement).name == "theme" {
+				errBundle.Add(tokenErrorInfo(statement.token, compilation, "A theme may not use another theme"))
+			}
+		}
+	}
+
+	if errBundle.HasErrors() {
+		return errBundle
+	}
+
+	return nil
+}
+
+// Find the theme named by a @theme directive, if there is one
+func themeDirective(statements []Statement) string {
+	for _, statement := range statements {
+		if statement.Type != DirectiveAssignment {
+			continue
+		}
+
+		directive := statement.data.(DirectiveStatement)
+		if name, ok := directive.value.(string); ok && directive.name == "theme" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// The built-in themes share their layout, differing only in these values
+type themeValues struct {
+	Description string
+	Background  string
+	Foreground  string
+	Accent      string
+	Muted       string
+	ChartColors string
+	Font        string
+	FontSize    int
+	HeadingSize int
+	// Where the title slide's title, the content slide's title
+	// and the content slide's body start, as percentages
+	TitleY   int
+	HeadingY int
+	BodyY    int
+}
+
+var themeTemplate = template.Must(template.New("theme").Parse(`
+# {{ .Description }}
+let background = {{ .Background }};
+let foreground = {{ .Foreground }};
+let accent = {{ .Accent }};
+let muted = {{ .Muted }};
+let chartColors = palette(accent, {{ .ChartColors }}, muted);
+
+style default {
+    self.font = fontStack({{ .Font }});
+    self.fontSize = {{ .FontSize }};
+    self.fontColor = foreground;
+}
+
+style heading {
+    self.fontSize = {{ .HeadingSize }};
+    self.fontColor = accent;
+}
+
+master default {
+    self.backgroundColor = background;
+}
+
+master title {
+    block title : heading {
+        self.justify = "center";
+        self.x = 5;
+        self.y = {{ .TitleY }};
+        self.width = 90;
+    }
+
+    block subtitle {
+        self.justify = "center";
+        self.fontColor = muted;
+        self.x = 5;
+        self.y = 55;
+        self.width = 90;
+    }
+}
+
+master content {
+    block title : heading {
+        self.x = 5;
+        self.y = {{ .HeadingY }};
+        self.width = 90;
+    }
+
+    block body {
+        self.x = 5;
+        self.y = {{ .BodyY }};
+        self.width = 90;
+    }
+}
+`))
+
+func themeSource(values themeValues) string {
+	source := strings.Builder{}
+	if err := themeTemplate.Execute(&source, values); err != nil {
+		panic(err)
+	}
+
+	return source.String()
+}
+
+var lightTheme = themeSource(themeValues{
+	Description: "A light theme with dark text on a white background",
+	Background:  "(255, 255, 255)",
+	Foreground:  "(33, 37, 41)",
+	Accent:      "(0, 102, 204)",
+	Muted:       "(108, 117, 125)",
+	ChartColors: "(230, 126, 34), (39, 174, 96), (192, 57, 43)",
+	Font:        `"Helvetica", "Arial", "sans-serif"`,
+	FontSize:    28,
+	HeadingSize: 48,
+	TitleY:      35,
+	HeadingY:    6,
+	BodyY:       22,
+})
+
+var darkTheme = themeSource(themeValues{
+	Description: "A dark theme with light text on a charcoal background",
+	Background:  "(30, 30, 30)",
+	Foreground:  "(230, 230, 230)",
+	Accent:      "(78, 205, 196)",
+	Muted:       "(150, 150, 150)",
+	ChartColors: "(255, 183, 77), (129, 199, 132), (229, 115, 115)",
+	Font:        `"Helvetica", "Arial", "sans-serif"`,
+	FontSize:    28,
+	HeadingSize: 48,
+	TitleY:      35,
+	HeadingY:    6,
+	BodyY:       22,
+})
+
+var highContrastTheme = themeSource(themeValues{
+	Description: "A high contrast theme with large, bright text on a black background",
+	Background:  "(0, 0, 0)",
+	Foreground:  "(255, 255, 255)",
+	Accent:      "(255, 221, 0)",
+	Muted:       "(255, 255, 255)",
+	ChartColors: "(0, 255, 255), (255, 0, 255), (0, 255, 0)",
+	Font:        `"Verdana", "Arial", "sans-serif"`,
+	FontSize:    36,
+	HeadingSize: 60,
+	TitleY:      30,
+	HeadingY:    5,
+	BodyY:       24,
+})
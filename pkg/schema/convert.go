@@ -0,0 +1,567 @@
+package schema
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/mbStavola/slydes/pkg/diagram"
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// The names of each enumeration, indexed by value. A name which is left
+// out of a document stands for the first
+var (
+	justifications    = []string{"left", "right", "center"}
+	gradientKinds     = []string{"linear", "radial"}
+	imageFits         = []string{"fit", "cover", "tile"}
+	diagramKinds      = []string{"flowchart", "sequence"}
+	diagramDirections = []string{"down", "right"}
+	chartKinds        = []string{"bar", "line", "pie"}
+	codeThemes        = []string{"light", "dark"}
+	shapeKinds        = []string{"rectangle", "ellipse", "line", "arrow"}
+	transforms        = []string{"none", "uppercase", "lowercase", "capitalize"}
+	alignments        = []string{"top", "middle", "bottom"}
+)
+
+func lookup(names []string, name string, kind string) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+
+	for i, candidate := range names {
+		if candidate == name {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown %s %q", kind, name)
+}
+
+// Encode converts a show into the current version of the schema
+func Encode(show types.Show) Show {
+	document := Show{
+		Version:    Version,
+		Metadata:   Metadata(show.Metadata),
+		Dimensions: Dimensions(show.Dimensions),
+		Slides:     make([]Slide, len(show.Slides)),
+	}
+
+	for _, font := range show.Fonts {
+		document.Fonts = append(document.Fonts, FontFace(font))
+	}
+
+	for i, slide := range show.Slides {
+		document.Slides[i] = encodeSlide(slide)
+	}
+
+	return document
+}
+
+func encodeSlide(slide types.Slide) Slide {
+	encoded := Slide{
+		Background: Hex(slide.Background),
+		Header:     slide.Header,
+		Footer:     slide.Footer,
+		Number:     slide.Number,
+		Notes:      slide.Notes,
+		Blocks:     make([]Block, len(slide.Blocks)),
+	}
+
+	if gradient := slide.BackgroundGradient; gradient != nil {
+		encoded.BackgroundGradient = &Gradient{
+			Kind:  gradientKinds[gradient.Kind],
+			Angle: gradient.Angle,
+			Stops: hexes(gradient.Stops),
+		}
+	}
+
+	if image := slide.BackgroundImage; image != nil {
+		encoded.BackgroundImage = &Image{Path: image.Path, Fit: imageFits[image.Fit]}
+	}
+
+	for i, block := range slide.Blocks {
+		encoded.Blocks[i] = encodeBlock(block)
+	}
+
+	for _, shape := range slide.Shapes {
+		encoded.Shapes = append(encoded.Shapes, encodeShape(shape))
+	}
+
+	return encoded
+}
+
+func encodeBlock(block types.Block) Block {
+	style := block.Style
+	encoded := Block{
+		Words: block.Words,
+		Style: Style{
+			Color:             Hex(style.Color),
+			Font:              style.Font,
+			FontFallbacks:     style.FontFallbacks,
+			Size:              style.Size,
+			Justification:     justifications[style.Justification],
+			Weight:            style.Weight,
+			Italic:            style.Italic,
+			Underline:         style.Underline,
+			Strikethrough:     style.Strikethrough,
+			LetterSpacing:     style.LetterSpacing,
+			LineHeight:        style.LineHeight,
+			Transform:         transforms[style.Transform],
+			VerticalAlignment: alignments[style.VerticalAlignment],
+			Padding:           style.Padding,
+		},
+	}
+
+	if block.Positioned {
+		frame := Frame(block.Frame)
+		encoded.Frame = &frame
+	}
+
+	if code := block.Code; code != nil {
+		encoded.Code = &Code{
+			Language:    code.Language,
+			LineNumbers: code.LineNumbers,
+			Theme:       codeThemes[code.Theme],
+		}
+
+		for _, lines := range code.Highlights {
+			encoded.Code.Highlights = append(encoded.Code.Highlights, LineRange(lines))
+		}
+	}
+
+	if table := block.Table; table != nil {
+		encoded.Table = &Table{
+			Rows:             table.Rows,
+			HeaderRow:        table.HeaderRow,
+			Border:           table.Border,
+			BorderColor:      Hex(table.BorderColor),
+			HeaderBackground: Hex(table.HeaderBackground),
+		}
+
+		for _, alignment := range table.ColumnAlignments {
+			encoded.Table.ColumnAlignments = append(encoded.Table.ColumnAlignments, justifications[alignment])
+		}
+	}
+
+	if diagram := block.Diagram; diagram != nil {
+		encoded.Diagram = &Diagram{
+			Kind:      diagramKinds[diagram.Kind],
+			Direction: diagramDirections[diagram.Direction],
+			Nodes:     make([]DiagramNode, len(diagram.Nodes)),
+			Edges:     make([]DiagramEdge, len(diagram.Edges)),
+		}
+
+		for i, node := range diagram.Nodes {
+			encoded.Diagram.Nodes[i] = DiagramNode(node)
+		}
+		for i, edge := range diagram.Edges {
+			encoded.Diagram.Edges[i] = DiagramEdge(edge)
+		}
+	}
+
+	if chart := block.Chart; chart != nil {
+		encoded.Chart = &Chart{
+			Kind:       chartKinds[chart.Kind],
+			Categories: chart.Categories,
+			Series:     make([]ChartSeries, len(chart.Series)),
+			XLabel:     chart.XLabel,
+			YLabel:     chart.YLabel,
+			Colors:     hexes(chart.Colors),
+		}
+
+		for i, series := range chart.Series {
+			encoded.Chart.Series[i] = ChartSeries(series)
+		}
+	}
+
+	return encoded
+}
+
+func encodeShape(shape types.Shape) Shape {
+	encoded := Shape{
+		Kind:        shapeKinds[shape.Kind],
+		Fill:        Hex(shape.Fill),
+		Stroke:      Hex(shape.Stroke),
+		StrokeWidth: shape.StrokeWidth,
+	}
+
+	switch shape.Kind {
+	case types.Line, types.Arrow:
+		from, to := Point(shape.From), Point(shape.To)
+		encoded.From, encoded.To = &from, &to
+	default:
+		encoded.Frame = encodeFrame(shape.Frame)
+	}
+
+	return encoded
+}
+
+// Shapes are always positioned, so a zero frame is as good as none
+func encodeFrame(frame types.Frame) *Frame {
+	if frame.IsZero() {
+		return nil
+	}
+
+	encoded := Frame(frame)
+	return &encoded
+}
+
+func hexes(colors []color.Color) []string {
+	if len(colors) == 0 {
+		return nil
+	}
+
+	encoded := make([]string, len(colors))
+	for i, c := range colors {
+		encoded[i] = Hex(c)
+	}
+
+	return encoded
+}
+
+// Decode converts a document back into a show, failing on documents
+// written in another version of the schema
+func Decode(document Show) (types.Show, error) {
+	if document.Version != Version {
+		return types.Show{}, fmt.Errorf("can't read version %d of the schema, only version %d", document.Version, Version)
+	}
+
+	show := types.NewShow()
+	show.Metadata = types.Metadata(document.Metadata)
+	if document.Dimensions.Width > types.MaxDimension || document.Dimensions.Height > types.MaxDimension {
+		return types.Show{}, fmt.Errorf("dimensions can't be larger than %d pixels a side", types.MaxDimension)
+	}
+	if document.Dimensions.Width != 0 && document.Dimensions.Height != 0 {
+		show.Dimensions = types.Dimensions(document.Dimensions)
+	}
+
+	for _, font := range document.Fonts {
+		show.Fonts = append(show.Fonts, types.FontFace(font))
+	}
+
+	for i, slide := range document.Slides {
+		decoded, err := decodeSlide(slide)
+		if err != nil {
+			return types.Show{}, fmt.Errorf("slide %d: %w", i+1, err)
+		}
+
+		show.Slides = append(show.Slides, decoded)
+	}
+
+	return show, nil
+}
+
+func decodeSlide(slide Slide) (types.Slide, error) {
+	decoded := types.NewSlide()
+	decoded.Header = slide.Header
+	decoded.Footer = slide.Footer
+	decoded.Number = slide.Number
+	decoded.Notes = slide.Notes
+
+	if slide.Background != "" {
+		background, err := ParseHex(slide.Background)
+		if err != nil {
+			return decoded, err
+		}
+		decoded.Background = background
+	}
+
+	if gradient := slide.BackgroundGradient; gradient != nil {
+		kind, err := lookup(gradientKinds, gradient.Kind, "gradient kind")
+		if err != nil {
+			return decoded, err
+		}
+
+		// A gradient blends between colors, as the gradient functions insist
+		if len(gradient.Stops) < 2 {
+			return decoded, fmt.Errorf("a gradient needs at least two stops")
+		}
+
+		stops, err := parseHexes(gradient.Stops)
+		if err != nil {
+			return decoded, err
+		}
+
+		decoded.BackgroundGradient = &types.Gradient{Kind: types.GradientKind(kind), Angle: gradient.Angle, Stops: stops}
+	}
+
+	if image := slide.BackgroundImage; image != nil {
+		fit, err := lookup(imageFits, image.Fit, "image fit")
+		if err != nil {
+			return decoded, err
+		}
+
+		decoded.BackgroundImage = &types.Image{Path: image.Path, Fit: types.ImageFit(fit)}
+	}
+
+	for i, block := range slide.Blocks {
+		decodedBlock, err := decodeBlock(block)
+		if err != nil {
+			return decoded, fmt.Errorf("block %d: %w", i+1, err)
+		}
+
+		decoded.Blocks = append(decoded.Blocks, decodedBlock)
+	}
+
+	for i, shape := range slide.Shapes {
+		decodedShape, err := decodeShape(shape)
+		if err != nil {
+			return decoded, fmt.Errorf("shape %d: %w", i+1, err)
+		}
+
+		decoded.Shapes = append(decoded.Shapes, decodedShape)
+	}
+
+	return decoded, nil
+}
+
+func decodeBlock(block Block) (types.Block, error) {
+	decoded := types.NewBlock()
+	decoded.Words = block.Words
+	if block.Frame != nil {
+		decoded.Frame = types.Frame(*block.Frame)
+		decoded.Positioned = true
+	}
+
+	style, err := decodeStyle(block.Style)
+	if err != nil {
+		return decoded, err
+	}
+	decoded.Style = style
+
+	if code := block.Code; code != nil {
+		theme, err := lookup(codeThemes, code.Theme, "code theme")
+		if err != nil {
+			return decoded, err
+		}
+
+		decoded.Code = &types.Code{Language: code.Language, LineNumbers: code.LineNumbers, Theme: types.CodeTheme(theme)}
+		for _, lines := range code.Highlights {
+			decoded.Code.Highlights = append(decoded.Code.Highlights, types.LineRange(lines))
+		}
+	}
+
+	if table := block.Table; table != nil {
+		decodedTable, err := decodeTable(*table)
+		if err != nil {
+			return decoded, err
+		}
+		decoded.Table = &decodedTable
+	}
+
+	if diagram := block.Diagram; diagram != nil {
+		decodedDiagram, err := decodeDiagram(*diagram)
+		if err != nil {
+			return decoded, err
+		}
+		decoded.Diagram = &decodedDiagram
+	}
+
+	if chart := block.Chart; chart != nil {
+		decodedChart, err := decodeChart(*chart)
+		if err != nil {
+			return decoded, err
+		}
+		decoded.Chart = &decodedChart
+	}
+
+	return decoded, nil
+}
+
+func decodeStyle(style Style) (types.Style, error) {
+	decoded := types.NewStyle()
+	decoded.FontFallbacks = style.FontFallbacks
+	decoded.Italic = style.Italic
+	decoded.Underline = style.Underline
+	decoded.Strikethrough = style.Strikethrough
+	decoded.LetterSpacing = style.LetterSpacing
+	decoded.LineHeight = style.LineHeight
+	decoded.Padding = style.Padding
+
+	if style.Color != "" {
+		c, err := ParseHex(style.Color)
+		if err != nil {
+			return decoded, err
+		}
+		decoded.Color = c
+	}
+	if style.Font != "" {
+		decoded.Font = style.Font
+	}
+	if style.Size != 0 {
+		decoded.Size = style.Size
+	}
+	if style.Weight != 0 {
+		decoded.Weight = style.Weight
+	}
+
+	justification, err := lookup(justifications, style.Justification, "justification")
+	if err != nil {
+		return decoded, err
+	}
+	decoded.Justification = types.Justification(justification)
+
+	transform, err := lookup(transforms, style.Transform, "text transform")
+	if err != nil {
+		return decoded, err
+	}
+	decoded.Transform = types.TextTransform(transform)
+
+	alignment, err := lookup(alignments, style.VerticalAlignment, "vertical alignment")
+	if err != nil {
+		return decoded, err
+	}
+	decoded.VerticalAlignment = types.VerticalAlignment(alignment)
+
+	return decoded, nil
+}
+
+func decodeTable(table Table) (types.Table, error) {
+	decoded := types.Table{HeaderRow: table.HeaderRow, Border: table.Border}
+
+	// Short rows are padded, as they are in a table block's text,
+	// so that every row has a cell for each column
+	columns := 0
+	for _, row := range table.Rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	for _, row := range table.Rows {
+		padded := make([]string, columns)
+		copy(padded, row)
+		decoded.Rows = append(decoded.Rows, padded)
+	}
+
+	for _, name := range table.ColumnAlignments {
+		alignment, err := lookup(justifications, name, "column alignment")
+		if err != nil {
+			return decoded, err
+		}
+
+		decoded.ColumnAlignments = append(decoded.ColumnAlignments, types.Justification(alignment))
+	}
+
+	var err error
+	if decoded.BorderColor, err = ParseHex(table.BorderColor); err != nil {
+		return decoded, err
+	}
+	if decoded.HeaderBackground, err = ParseHex(table.HeaderBackground); err != nil {
+		return decoded, err
+	}
+
+	// Borders need a color to be drawn in
+	if decoded.BorderColor == nil && decoded.Border != 0 {
+		decoded.BorderColor = types.NewTable().BorderColor
+	}
+
+	return decoded, nil
+}
+
+func decodeDiagram(encoded Diagram) (types.Diagram, error) {
+	kind, err := lookup(diagramKinds, encoded.Kind, "diagram kind")
+	if err != nil {
+		return types.Diagram{}, err
+	}
+
+	direction, err := lookup(diagramDirections, encoded.Direction, "diagram direction")
+	if err != nil {
+		return types.Diagram{}, err
+	}
+
+	decoded := types.Diagram{Kind: types.DiagramKind(kind), Direction: types.DiagramDirection(direction)}
+	for _, node := range encoded.Nodes {
+		decoded.Nodes = append(decoded.Nodes, types.DiagramNode(node))
+	}
+	for _, edge := range encoded.Edges {
+		decoded.Edges = append(decoded.Edges, types.DiagramEdge(edge))
+	}
+
+	if err := diagram.Validate(decoded); err != nil {
+		return decoded, fmt.Errorf("malformed diagram: %w", err)
+	}
+
+	return decoded, nil
+}
+
+func decodeChart(chart Chart) (types.Chart, error) {
+	decoded := types.NewChart()
+	decoded.Categories = chart.Categories
+	decoded.XLabel = chart.XLabel
+	decoded.YLabel = chart.YLabel
+
+	kind, err := lookup(chartKinds, chart.Kind, "chart kind")
+	if err != nil {
+		return decoded, err
+	}
+	decoded.Kind = types.ChartKind(kind)
+
+	// Every series needs a number for each category, as a chart block's text would give
+	for _, series := range chart.Series {
+		if len(series.Values) != len(chart.Categories) {
+			return decoded, fmt.Errorf("series %q has %d values for %d categories", series.Name, len(series.Values), len(chart.Categories))
+		}
+
+		for i, value := range series.Values {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				return decoded, fmt.Errorf("chart value %v for %s is not a number", value, chart.Categories[i])
+			}
+		}
+
+		decoded.Series = append(decoded.Series, types.ChartSeries(series))
+	}
+
+	if len(chart.Colors) > 0 {
+		if decoded.Colors, err = parseHexes(chart.Colors); err != nil {
+			return decoded, err
+		}
+	}
+
+	return decoded, nil
+}
+
+func decodeShape(shape Shape) (types.Shape, error) {
+	kind, err := lookup(shapeKinds, shape.Kind, "shape kind")
+	if err != nil {
+		return types.Shape{}, err
+	}
+
+	decoded := types.Shape{Kind: types.ShapeKind(kind), StrokeWidth: shape.StrokeWidth}
+	if shape.Frame != nil {
+		decoded.Frame = types.Frame(*shape.Frame)
+	}
+	if shape.From != nil {
+		decoded.From = types.Point(*shape.From)
+	}
+	if shape.To != nil {
+		decoded.To = types.Point(*shape.To)
+	}
+
+	if decoded.Fill, err = ParseHex(shape.Fill); err != nil {
+		return decoded, err
+	}
+	if decoded.Stroke, err = ParseHex(shape.Stroke); err != nil {
+		return decoded, err
+	}
+
+	return decoded, nil
+}
+
+func parseHexes(colors []string) ([]color.Color, error) {
+	decoded := make([]color.Color, len(colors))
+	for i, text := range colors {
+		c, err := ParseHex(text)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, fmt.Errorf("color %d is missing", i+1)
+		}
+
+		decoded[i] = c
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,304 @@
+// Package schema is a stable encoding of compiled shows, for other tools to
+// read and write as JSON or YAML without depending on Slydes' own types
+//
+// Every document names the Version of the schema it was written in. Fields
+// may be added to a version, but never renamed or given a new meaning;
+// anything else needs a new version. Colors are hex strings ("#rrggbb", or
+// "#rrggbbaa" when not opaque) and enumerations are lowercase names, which
+// follow Sly where it has a name for them
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// Version is the version of the schema this package reads and writes
+const Version = 1
+
+type Show struct {
+	Version    int        `json:"version" yaml:"version"`
+	Metadata   Metadata   `json:"metadata" yaml:"metadata"`
+	Dimensions Dimensions `json:"dimensions" yaml:"dimensions"`
+	Fonts      []FontFace `json:"fonts,omitempty" yaml:"fonts,omitempty"`
+	Slides     []Slide    `json:"slides" yaml:"slides"`
+}
+
+type Metadata struct {
+	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
+	Author      string `json:"author,omitempty" yaml:"author,omitempty"`
+	Date        string `json:"date,omitempty" yaml:"date,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Language    string `json:"language,omitempty" yaml:"language,omitempty"`
+}
+
+type Dimensions struct {
+	Width  uint `json:"width" yaml:"width"`
+	Height uint `json:"height" yaml:"height"`
+}
+
+type FontFace struct {
+	Family string `json:"family" yaml:"family"`
+	Path   string `json:"path" yaml:"path"`
+}
+
+// A slide's background is white when left out
+type Slide struct {
+	Background         string    `json:"background,omitempty" yaml:"background,omitempty"`
+	BackgroundGradient *Gradient `json:"backgroundGradient,omitempty" yaml:"backgroundGradient,omitempty"`
+	BackgroundImage    *Image    `json:"backgroundImage,omitempty" yaml:"backgroundImage,omitempty"`
+	Header             string    `json:"header,omitempty" yaml:"header,omitempty"`
+	Footer             string    `json:"footer,omitempty" yaml:"footer,omitempty"`
+	Number             string    `json:"number,omitempty" yaml:"number,omitempty"`
+	Notes              string    `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Blocks             []Block   `json:"blocks" yaml:"blocks"`
+	Shapes             []Shape   `json:"shapes,omitempty" yaml:"shapes,omitempty"`
+}
+
+type Gradient struct {
+	// One of linear or radial
+	Kind  string   `json:"kind" yaml:"kind"`
+	Angle uint     `json:"angle,omitempty" yaml:"angle,omitempty"`
+	Stops []string `json:"stops" yaml:"stops"`
+}
+
+type Image struct {
+	Path string `json:"path" yaml:"path"`
+	// One of fit, cover or tile
+	Fit string `json:"fit" yaml:"fit"`
+}
+
+type Block struct {
+	Words   string   `json:"words" yaml:"words"`
+	Style   Style    `json:"style" yaml:"style"`
+	Frame   *Frame   `json:"frame,omitempty" yaml:"frame,omitempty"`
+	Code    *Code    `json:"code,omitempty" yaml:"code,omitempty"`
+	Table   *Table   `json:"table,omitempty" yaml:"table,omitempty"`
+	Diagram *Diagram `json:"diagram,omitempty" yaml:"diagram,omitempty"`
+	Chart   *Chart   `json:"chart,omitempty" yaml:"chart,omitempty"`
+}
+
+// A style's color, font, size and weight are Sly's defaults when left out
+type Style struct {
+	Color         string   `json:"color,omitempty" yaml:"color,omitempty"`
+	Font          string   `json:"font,omitempty" yaml:"font,omitempty"`
+	FontFallbacks []string `json:"fontFallbacks,omitempty" yaml:"fontFallbacks,omitempty"`
+	Size          uint8    `json:"size,omitempty" yaml:"size,omitempty"`
+	// One of left, right or center
+	Justification string  `json:"justification,omitempty" yaml:"justification,omitempty"`
+	Weight        uint16  `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Italic        bool    `json:"italic,omitempty" yaml:"italic,omitempty"`
+	Underline     bool    `json:"underline,omitempty" yaml:"underline,omitempty"`
+	Strikethrough bool    `json:"strikethrough,omitempty" yaml:"strikethrough,omitempty"`
+	LetterSpacing float64 `json:"letterSpacing,omitempty" yaml:"letterSpacing,omitempty"`
+	LineHeight    float64 `json:"lineHeight,omitempty" yaml:"lineHeight,omitempty"`
+	// One of none, uppercase, lowercase or capitalize
+	Transform string `json:"transform,omitempty" yaml:"transform,omitempty"`
+	// One of top, middle or bottom
+	VerticalAlignment string `json:"verticalAlignment,omitempty" yaml:"verticalAlignment,omitempty"`
+	Padding           uint   `json:"padding,omitempty" yaml:"padding,omitempty"`
+}
+
+type Frame struct {
+	X      uint `json:"x" yaml:"x"`
+	Y      uint `json:"y" yaml:"y"`
+	Width  uint `json:"width,omitempty" yaml:"width,omitempty"`
+	Height uint `json:"height,omitempty" yaml:"height,omitempty"`
+}
+
+type Code struct {
+	Language    string      `json:"language,omitempty" yaml:"language,omitempty"`
+	LineNumbers bool        `json:"lineNumbers,omitempty" yaml:"lineNumbers,omitempty"`
+	Highlights  []LineRange `json:"highlights,omitempty" yaml:"highlights,omitempty"`
+	// One of light or dark
+	Theme string `json:"theme,omitempty" yaml:"theme,omitempty"`
+}
+
+type LineRange struct {
+	Start uint `json:"start" yaml:"start"`
+	End   uint `json:"end" yaml:"end"`
+}
+
+type Table struct {
+	Rows      [][]string `json:"rows" yaml:"rows"`
+	HeaderRow bool       `json:"headerRow" yaml:"headerRow"`
+	// Each one of left, right or center
+	ColumnAlignments []string `json:"columnAlignments,omitempty" yaml:"columnAlignments,omitempty"`
+	Border           uint     `json:"border" yaml:"border"`
+	BorderColor      string   `json:"borderColor,omitempty" yaml:"borderColor,omitempty"`
+	HeaderBackground string   `json:"headerBackground,omitempty" yaml:"headerBackground,omitempty"`
+}
+
+type Diagram struct {
+	// One of flowchart or sequence
+	Kind string `json:"kind" yaml:"kind"`
+	// One of down or right
+	Direction string        `json:"direction,omitempty" yaml:"direction,omitempty"`
+	Nodes     []DiagramNode `json:"nodes" yaml:"nodes"`
+	Edges     []DiagramEdge `json:"edges" yaml:"edges"`
+}
+
+type DiagramNode struct {
+	ID    string `json:"id" yaml:"id"`
+	Label string `json:"label" yaml:"label"`
+}
+
+type DiagramEdge struct {
+	From   string `json:"from" yaml:"from"`
+	To     string `json:"to" yaml:"to"`
+	Label  string `json:"label,omitempty" yaml:"label,omitempty"`
+	Dashed bool   `json:"dashed,omitempty" yaml:"dashed,omitempty"`
+}
+
+// A chart's colors are Sly's defaults when left out
+type Chart struct {
+	// One of bar, line or pie
+	Kind       string        `json:"kind" yaml:"kind"`
+	Categories []string      `json:"categories" yaml:"categories"`
+	Series     []ChartSeries `json:"series" yaml:"series"`
+	XLabel     string        `json:"xLabel,omitempty" yaml:"xLabel,omitempty"`
+	YLabel     string        `json:"yLabel,omitempty" yaml:"yLabel,omitempty"`
+	Colors     []string      `json:"colors,omitempty" yaml:"colors,omitempty"`
+}
+
+type ChartSeries struct {
+	Name   string    `json:"name" yaml:"name"`
+	Values []float64 `json:"values" yaml:"values"`
+}
+
+type Shape struct {
+	// One of rectangle, ellipse, line or arrow
+	Kind  string `json:"kind" yaml:"kind"`
+	Frame *Frame `json:"frame,omitempty" yaml:"frame,omitempty"`
+	From  *Point `json:"from,omitempty" yaml:"from,omitempty"`
+	To    *Point `json:"to,omitempty" yaml:"to,omitempty"`
+	// Left out when not drawn
+	Fill        string `json:"fill,omitempty" yaml:"fill,omitempty"`
+	Stroke      string `json:"stroke,omitempty" yaml:"stroke,omitempty"`
+	StrokeWidth uint   `json:"strokeWidth,omitempty" yaml:"strokeWidth,omitempty"`
+}
+
+type Point struct {
+	X uint `json:"x" yaml:"x"`
+	Y uint `json:"y" yaml:"y"`
+}
+
+// WriteJSON encodes the show as an indented JSON document
+func WriteJSON(w io.Writer, show types.Show) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(Encode(show))
+}
+
+// WriteYAML encodes the show as a YAML document
+func WriteYAML(w io.Writer, show types.Show) error {
+	// The YAML encoder writes multiline strings as block scalars, which
+	// lose blank lines and tabs at their start, as the words of a block
+	// often have. So the document is built from its JSON, which YAML can
+	// read, and multiline strings are quoted instead
+	text, err := json.Marshal(Encode(show))
+	if err != nil {
+		return err
+	}
+
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(text, &document); err != nil {
+		return err
+	}
+	restyle(&document)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&document); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+func restyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "\n") {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+
+	for _, child := range node.Content {
+		restyle(child)
+	}
+}
+
+// ReadJSON decodes a show from a JSON document. Unknown fields are an
+// error, so that mistakes in hand written documents are caught
+func ReadJSON(r io.Reader) (types.Show, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	document := Show{}
+	if err := decoder.Decode(&document); err != nil {
+		return types.Show{}, err
+	}
+
+	return Decode(document)
+}
+
+// ReadYAML decodes a show from a YAML document. Unknown fields are an
+// error, as they are for JSON
+func ReadYAML(r io.Reader) (types.Show, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+
+	document := Show{}
+	if err := decoder.Decode(&document); err != nil {
+		return types.Show{}, err
+	}
+
+	return Decode(document)
+}
+
+// Hex writes a color as "#rrggbb", with the alpha added when not opaque,
+// or as an empty string for nil
+func Hex(c color.Color) string {
+	if c == nil {
+		return ""
+	}
+
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B)
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B, nrgba.A)
+}
+
+// ParseHex reads a color written by Hex, where an empty string is nil
+func ParseHex(text string) (color.Color, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	digits := strings.TrimPrefix(text, "#")
+	if digits == text || (len(digits) != 6 && len(digits) != 8) {
+		return nil, fmt.Errorf("color %q must be written as #rrggbb or #rrggbbaa", text)
+	}
+
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("color %q must be written as #rrggbb or #rrggbbaa", text)
+	}
+
+	if len(digits) == 6 {
+		value = value<<8 | 0xff
+	}
+
+	return color.NRGBA{R: uint8(value >> 24), G: uint8(value >> 16), B: uint8(value >> 8), A: uint8(value)}, nil
+}
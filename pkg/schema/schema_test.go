@@ -0,0 +1,263 @@
+package schema
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/mbStavola/slydes/pkg/lang"
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+const source = `
+@title = "Launch";
+@width = 1024;
+@height = 768;
+
+slide first {
+	self.backgroundGradient = linearGradient(90, "white", #ff000080);
+	self.notes = "Smile";
+
+	block intro {
+		self.fontColor = "navy";
+		self.justify = "center";
+		self.italic = true;
+		self.x = 0;
+		self.y = 0;
+		---
+
+
+		Launch plan
+		---
+	}
+
+	code snippet {
+		self.language = "go";
+		self.highlight = "1, 3-4";
+		self.codeTheme = "dark";
+		---fmt.Println("hi")---
+	}
+
+	rect highlight {
+		self.x = 10;
+		self.y = 20;
+		self.width = 30;
+		self.height = 40;
+		self.fill = "gold";
+		self.stroke = "none";
+	}
+
+	arrow pointer {
+		self.x1 = 50;
+		self.y1 = 50;
+		self.x2 = 40;
+		self.y2 = 30;
+	}
+}
+
+slide second {
+	diagram flow {
+		self.direction = "right";
+		---
+		web -> api: GET
+		api --> db
+		---
+	}
+
+	chart visitors {
+		self.chartType = "line";
+		---
+		Month, Web, App
+		Jan, 10, 2.5
+		---
+	}
+
+	table prices {
+		---
+		Plan, Price
+		Pro, 10
+		---
+	}
+}`
+
+func TestRoundTrip(t *testing.T) {
+	show, err := lang.NewSly().ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	formats := []struct {
+		name  string
+		write func(*bytes.Buffer, types.Show) error
+		read  func(*bytes.Buffer) (types.Show, error)
+	}{
+		{
+			name:  "JSON",
+			write: func(b *bytes.Buffer, show types.Show) error { return WriteJSON(b, show) },
+			read:  func(b *bytes.Buffer) (types.Show, error) { return ReadJSON(b) },
+		},
+		{
+			name:  "YAML",
+			write: func(b *bytes.Buffer, show types.Show) error { return WriteYAML(b, show) },
+			read:  func(b *bytes.Buffer) (types.Show, error) { return ReadYAML(b) },
+		},
+	}
+
+	for _, format := range formats {
+		written := bytes.Buffer{}
+		if err := format.write(&written, show); err != nil {
+			t.Error(err)
+			return
+		}
+		document := written.String()
+
+		decoded, err := format.read(&written)
+		if err != nil {
+			t.Errorf("Expected %s to be read back-- got %v", format.name, err)
+			return
+		}
+
+		rewritten := bytes.Buffer{}
+		if err := format.write(&rewritten, decoded); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if rewritten.String() != document {
+			t.Errorf("Expected %s to survive a round trip-- got\n%s\ninstead of\n%s", format.name, rewritten.String(), document)
+			return
+		}
+
+		if decoded.Dimensions.Width != 1024 || decoded.Metadata.Title != "Launch" || len(decoded.Slides) != 2 {
+			t.Errorf("Expected the show's metadata and slides from %s-- got %+v", format.name, decoded)
+			return
+		}
+
+		if !decoded.Slides[0].Blocks[0].Positioned || decoded.Slides[0].Blocks[1].Positioned {
+			t.Errorf("Expected only the first block to be positioned from %s", format.name)
+			return
+		}
+
+		stops := decoded.Slides[0].BackgroundGradient.Stops
+		if r, _, _, a := stops[1].RGBA(); r>>8 != 128 || a>>8 != 128 {
+			t.Errorf("Expected a translucent red stop from %s-- got %v", format.name, stops[1])
+			return
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	show, err := lang.NewSly().ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	written := bytes.Buffer{}
+	if err := WriteJSON(&written, show); err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := []string{
+		`"version": 1`,
+		`"kind": "linear"`,
+		`"#ff000080"`,
+		`"color": "#000080"`,
+		`"justification": "center"`,
+		`"theme": "dark"`,
+		`"kind": "arrow"`,
+		`"direction": "right"`,
+		`"kind": "line"`,
+	}
+	for _, part := range expected {
+		if !strings.Contains(written.String(), part) {
+			t.Errorf("Expected the JSON to contain %s-- got %s", part, written.String())
+			return
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	document := `{
+		"version": 1,
+		"slides": [{"blocks": [{"words": "Hi", "style": {"size": 30}}, {"words": "", "chart": {"kind": "pie"}}]}]
+	}`
+
+	show, err := ReadJSON(strings.NewReader(document))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if show.Dimensions != types.DefaultDimensions() {
+		t.Errorf("Expected the default dimensions-- got %v", show.Dimensions)
+		return
+	}
+
+	slide := show.Slides[0]
+	if slide.Background != color.White {
+		t.Errorf("Expected a white background-- got %v", slide.Background)
+		return
+	}
+
+	style := slide.Blocks[0].Style
+	if style.Size != 30 || style.Font != "Times New Roman" || style.Color != color.Black || style.Weight != 400 {
+		t.Errorf("Expected Sly's defaults where the style leaves them out-- got %+v", style)
+		return
+	}
+
+	if chart := slide.Blocks[1].Chart; chart.Kind != types.PieChart || len(chart.Colors) != len(types.NewChart().Colors) {
+		t.Errorf("Expected a pie chart in the default colors-- got %+v", chart)
+	}
+}
+
+func TestRaggedTable(t *testing.T) {
+	document := `{"version": 1, "slides": [{"blocks": [{"words": "", "table": {"rows": [["a", "b", "c"], ["1"]]}}]}]}`
+
+	show, err := ReadJSON(strings.NewReader(document))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if rows := show.Slides[0].Blocks[0].Table.Rows; len(rows[1]) != 3 || rows[1][0] != "1" || rows[1][2] != "" {
+		t.Errorf("Expected the short row to be padded to three cells-- got %q", rows)
+	}
+}
+
+func TestInvalidDocuments(t *testing.T) {
+	documents := []string{
+		`{"slides": []}`,
+		`{"version": 2, "slides": []}`,
+		`{"version": 1, "dimensions": {"width": 4000000000, "height": 3000000000}, "slides": []}`,
+		`{"version": 1, "slides": [], "theme": "dark"}`,
+		`{"version": 1, "slides": [{"background": "red", "blocks": []}]}`,
+		`{"version": 1, "slides": [{"blocks": [{"words": "", "style": {"color": "#12345"}}]}]}`,
+		`{"version": 1, "slides": [{"blocks": [{"words": "", "style": {"justification": "justified"}}]}]}`,
+		`{"version": 1, "slides": [{"blocks": [], "shapes": [{"kind": "star"}]}]}`,
+		`{"version": 1, "slides": [{"blocks": [], "backgroundGradient": {"kind": "linear", "stops": [""]}}]}`,
+		`{"version": 1, "slides": [{"blocks": [], "backgroundGradient": {"kind": "linear", "stops": []}}]}`,
+		`{"version": 1, "slides": [{"blocks": [], "backgroundGradient": {"kind": "radial", "stops": ["#ff0000"]}}]}`,
+		`{"version": 1, "slides": [{"blocks": [{"diagram": {"nodes": []}}]}]}`,
+		`{"version": 1, "slides": [{"blocks": [{"diagram": {"nodes": [{"id": "a"}], "edges": [{"from": "a", "to": "b"}]}}]}]}`,
+		`{"version": 1, "slides": [{"blocks": [{"chart": {"categories": ["Q1", "Q2"], "series": [{"name": "Sales", "values": [1]}]}}]}]}`,
+	}
+
+	for _, document := range documents {
+		if _, err := ReadJSON(strings.NewReader(document)); err == nil {
+			t.Errorf("Expected an error for %s", document)
+		}
+	}
+
+	if _, err := ReadYAML(strings.NewReader("version: 1\nslides: []\nextra: true\n")); err == nil {
+		t.Errorf("Expected an error for an unknown YAML field")
+	}
+
+	// JSON has no way to write these, but YAML does
+	if _, err := ReadYAML(strings.NewReader("version: 1\nslides:\n- blocks:\n  - chart:\n      categories: [Q1]\n      series: [{name: Sales, values: [.nan]}]\n")); err == nil {
+		t.Errorf("Expected an error for a chart value which is not a number")
+	}
+}
@@ -0,0 +1,430 @@
+package tex
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// MathML converts a formula into a <math> element
+func MathML(formula string, display bool) (string, error) {
+	p := parser{tokens: tokenize(formula), display: display}
+
+	elements, err := p.expression("")
+	if err != nil {
+		return "", err
+	}
+
+	if t, ok := p.peek(); ok {
+		return "", fmt.Errorf("unexpected %s", t)
+	}
+
+	mode := "inline"
+	if display {
+		mode = "block"
+	}
+
+	// The source is kept as an annotation, so that copying
+	// a formula out of a slide gives back what was written
+	return fmt.Sprintf(
+		`<math xmlns="http://www.w3.org/1998/Math/MathML" display="%s"><semantics>%s<annotation encoding="application/x-tex">%s</annotation></semantics></math>`,
+		mode, row(elements), html.EscapeString(formula),
+	), nil
+}
+
+// A token is a command (ex: \frac or \{) or a single character
+type token string
+
+func (t token) isCommand() bool {
+	return len(t) > 1 && t[0] == '\\'
+}
+
+func (t token) String() string {
+	switch t {
+	case "{":
+		return "opening brace"
+	case "}":
+		return "closing brace"
+	}
+
+	return fmt.Sprintf("'%s'", string(t))
+}
+
+func tokenize(formula string) []token {
+	tokens := make([]token, 0, len(formula))
+	runes := []rune(formula)
+
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		switch {
+		case unicode.IsSpace(char):
+			// Spaces only matter within text, so runs of them are kept as one
+			if len(tokens) == 0 || tokens[len(tokens)-1] != " " {
+				tokens = append(tokens, " ")
+			}
+		case char == '\\' && i+1 < len(runes) && unicode.IsLetter(runes[i+1]):
+			end := i + 1
+			for end < len(runes) && unicode.IsLetter(runes[end]) {
+				end++
+			}
+
+			tokens = append(tokens, token(runes[i:end]))
+			i = end - 1
+		case char == '\\' && i+1 < len(runes):
+			tokens = append(tokens, token(runes[i:i+2]))
+			i++
+		default:
+			tokens = append(tokens, token(char))
+		}
+	}
+
+	return tokens
+}
+
+type parser struct {
+	tokens   []token
+	position int
+	display  bool
+}
+
+func (p *parser) peek() (token, bool) {
+	for p.position < len(p.tokens) && p.tokens[p.position] == " " {
+		p.position++
+	}
+
+	if p.position >= len(p.tokens) {
+		return "", false
+	}
+
+	return p.tokens[p.position], true
+}
+
+func (p *parser) next() (token, bool) {
+	t, ok := p.peek()
+	if ok {
+		p.position++
+	}
+
+	return t, ok
+}
+
+// Parse elements until the closing token, or the end of the formula when
+// there is none. The closing token itself is left for the caller to eat
+func (p *parser) expression(closing token) ([]string, error) {
+	elements := make([]string, 0)
+
+	for {
+		t, ok := p.peek()
+		if !ok {
+			if closing != "" {
+				return nil, fmt.Errorf("expected %s before the end of the formula", closing)
+			}
+
+			return elements, nil
+		}
+
+		if t == closing {
+			return elements, nil
+		} else if t == "}" || t == `\right` {
+			return nil, fmt.Errorf("unexpected %s", t)
+		}
+
+		element, err := p.scripted()
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, element)
+	}
+}
+
+// Parse an atom along with any subscript and superscript attached to it
+func (p *parser) scripted() (string, error) {
+	start, _ := p.peek()
+
+	base, err := p.atom()
+	if err != nil {
+		return "", err
+	}
+
+	sub := ""
+	sup := make([]string, 0)
+	for {
+		t, ok := p.peek()
+		if !ok || (t != "_" && t != "^" && t != "'") {
+			break
+		}
+
+		p.next()
+
+		if t == "'" {
+			sup = append(sup, "<mo>′</mo>")
+			continue
+		}
+
+		script, err := p.argument(t)
+		if err != nil {
+			return "", err
+		}
+
+		if t == "_" && sub != "" {
+			return "", fmt.Errorf("double subscript")
+		} else if t == "_" {
+			sub = script
+		} else if len(sup) > 0 && sup[len(sup)-1] != "<mo>′</mo>" {
+			return "", fmt.Errorf("double superscript")
+		} else {
+			sup = append(sup, script)
+		}
+	}
+
+	// Limits go above and below operators like \sum in display math
+	under, over := "msub", "msup"
+	if p.display && limits[start] {
+		under, over = "munder", "mover"
+	}
+
+	switch {
+	case sub != "" && len(sup) > 0 && under == "munder":
+		return fmt.Sprintf("<munderover>%s%s%s</munderover>", base, sub, row(sup)), nil
+	case sub != "" && len(sup) > 0:
+		return fmt.Sprintf("<msubsup>%s%s%s</msubsup>", base, sub, row(sup)), nil
+	case sub != "":
+		return fmt.Sprintf("<%s>%s%s</%s>", under, base, sub, under), nil
+	case len(sup) > 0:
+		return fmt.Sprintf("<%s>%s%s</%s>", over, base, row(sup), over), nil
+	}
+
+	return base, nil
+}
+
+// Parse a single argument to a command or script, which is either a group
+// in braces or a single atom (ex: the 2 in x^2)
+func (p *parser) argument(command token) (string, error) {
+	t, ok := p.peek()
+	if !ok {
+		return "", fmt.Errorf("expected an argument after %s", command)
+	} else if t == "^" || t == "_" || t == "}" {
+		return "", fmt.Errorf("expected an argument after %s-- got %s", command, t)
+	}
+
+	return p.atom()
+}
+
+// Read the raw text of a group in braces, for commands like \text
+func (p *parser) text(command token) (string, error) {
+	if t, ok := p.next(); !ok || t != "{" {
+		return "", fmt.Errorf("expected an opening brace after %s", command)
+	}
+
+	text := strings.Builder{}
+	depth := 0
+	for {
+		// Spaces are part of the text, so the tokens are read directly
+		ok := p.position < len(p.tokens)
+		t := token("")
+		if ok {
+			t = p.tokens[p.position]
+			p.position++
+		}
+
+		switch {
+		case !ok:
+			return "", fmt.Errorf("expected a closing brace after %s", command)
+		case t == "{":
+			depth++
+			continue
+		case t == "}" && depth == 0:
+			return text.String(), nil
+		case t == "}":
+			depth--
+			continue
+		}
+
+		// Escaped characters (ex: \{ or \%) are written without the backslash
+		if len(t) == 2 && t[0] == '\\' {
+			t = t[1:]
+		}
+
+		text.WriteString(string(t))
+	}
+}
+
+func (p *parser) atom() (string, error) {
+	t, _ := p.next()
+
+	switch {
+	case t == "^" || t == "_":
+		return "", fmt.Errorf("expected something before %s", t)
+	case t == "{":
+		elements, err := p.expression("}")
+		if err != nil {
+			return "", err
+		}
+
+		p.next()
+		return row(elements), nil
+	case len(t) == 1:
+		return character(rune(t[0])), nil
+	case !t.isCommand():
+		// A multi-byte character
+		return character([]rune(string(t))[0]), nil
+	}
+
+	name := string(t[1:])
+	if letter, ok := greek[name]; ok {
+		if unicode.IsUpper(letter) {
+			return fmt.Sprintf(`<mi mathvariant="normal">%c</mi>`, letter), nil
+		}
+
+		return fmt.Sprintf("<mi>%c</mi>", letter), nil
+	} else if symbol, ok := operators[name]; ok {
+		if large[name] {
+			return fmt.Sprintf(`<mo largeop="true">%s</mo>`, symbol), nil
+		}
+
+		return fmt.Sprintf("<mo>%s</mo>", symbol), nil
+	} else if functions[name] {
+		return fmt.Sprintf("<mi>%s</mi>", name), nil
+	} else if width, ok := spaces[name]; ok {
+		return fmt.Sprintf(`<mspace width="%s"/>`, width), nil
+	} else if accent, ok := accents[name]; ok {
+		base, err := p.argument(t)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf(`<mover accent="true">%s<mo>%s</mo></mover>`, base, accent), nil
+	} else if alphabet, ok := alphabets[name]; ok {
+		text, err := p.text(t)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf(`<mi mathvariant="normal">%s</mi>`, html.EscapeString(alphabet(text))), nil
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "binom":
+		numerator, err := p.argument(t)
+		if err != nil {
+			return "", err
+		}
+
+		denominator, err := p.argument(t)
+		if err != nil {
+			return "", err
+		}
+
+		if name == "binom" {
+			return fmt.Sprintf(`<mrow><mo>(</mo><mfrac linethickness="0">%s%s</mfrac><mo>)</mo></mrow>`, numerator, denominator), nil
+		}
+
+		return fmt.Sprintf("<mfrac>%s%s</mfrac>", numerator, denominator), nil
+	case "sqrt":
+		// An optional index comes in square brackets (ex: \sqrt[3]{x})
+		if next, _ := p.peek(); next == "[" {
+			p.next()
+
+			index, err := p.expression("]")
+			if err != nil {
+				return "", err
+			}
+
+			p.next()
+
+			radicand, err := p.argument(t)
+			if err != nil {
+				return "", err
+			}
+
+			return fmt.Sprintf("<mroot>%s%s</mroot>", radicand, row(index)), nil
+		}
+
+		radicand, err := p.argument(t)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("<msqrt>%s</msqrt>", radicand), nil
+	case "text", "textrm", "mbox":
+		text, err := p.text(t)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("<mtext>%s</mtext>", html.EscapeString(text)), nil
+	case "left":
+		opening, err := p.delimiter(t)
+		if err != nil {
+			return "", err
+		}
+
+		elements, err := p.expression(`\right`)
+		if err != nil {
+			return "", err
+		}
+
+		p.next()
+
+		closing, err := p.delimiter(`\right`)
+		if err != nil {
+			return "", err
+		}
+
+		return row(append(append([]string{opening}, elements...), closing)), nil
+	}
+
+	return "", fmt.Errorf("unknown command %s", t)
+}
+
+// Read the delimiter following \left or \right, where a period means none
+func (p *parser) delimiter(command token) (string, error) {
+	t, ok := p.next()
+	if !ok {
+		return "", fmt.Errorf("expected a delimiter after %s", command)
+	}
+
+	if t == "." {
+		return "<mo></mo>", nil
+	} else if symbol, ok := delimiters[t]; ok {
+		return fmt.Sprintf(`<mo stretchy="true">%s</mo>`, symbol), nil
+	}
+
+	return "", fmt.Errorf("%s is not a delimiter", t)
+}
+
+// Characters written directly in a formula
+func character(char rune) string {
+	switch {
+	case unicode.IsLetter(char):
+		return fmt.Sprintf("<mi>%c</mi>", char)
+	case unicode.IsDigit(char) || char == '.':
+		return fmt.Sprintf("<mn>%c</mn>", char)
+	case char == '-':
+		return "<mo>−</mo>"
+	}
+
+	return fmt.Sprintf("<mo>%s</mo>", html.EscapeString(string(char)))
+}
+
+// Group elements, merging neighbouring digits into one number
+func row(elements []string) string {
+	merged := make([]string, 0, len(elements))
+	for _, element := range elements {
+		last := len(merged) - 1
+		if last >= 0 && strings.HasPrefix(element, "<mn>") && strings.HasPrefix(merged[last], "<mn>") && !strings.Contains(merged[last], "</mn><") {
+			merged[last] = strings.TrimSuffix(merged[last], "</mn>") + strings.TrimPrefix(element, "<mn>")
+			continue
+		}
+
+		merged = append(merged, element)
+	}
+
+	if len(merged) == 1 {
+		return merged[0]
+	}
+
+	return "<mrow>" + strings.Join(merged, "") + "</mrow>"
+}
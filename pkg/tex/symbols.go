@@ -0,0 +1,298 @@
+package tex
+
+import "strings"
+
+var greek = map[string]rune{
+	"alpha":      'α',
+	"beta":       'β',
+	"gamma":      'γ',
+	"delta":      'δ',
+	"epsilon":    'ϵ',
+	"varepsilon": 'ε',
+	"zeta":       'ζ',
+	"eta":        'η',
+	"theta":      'θ',
+	"vartheta":   'ϑ',
+	"iota":       'ι',
+	"kappa":      'κ',
+	"lambda":     'λ',
+	"mu":         'μ',
+	"nu":         'ν',
+	"xi":         'ξ',
+	"pi":         'π',
+	"varpi":      'ϖ',
+	"rho":        'ρ',
+	"varrho":     'ϱ',
+	"sigma":      'σ',
+	"varsigma":   'ς',
+	"tau":        'τ',
+	"upsilon":    'υ',
+	"phi":        'ϕ',
+	"varphi":     'φ',
+	"chi":        'χ',
+	"psi":        'ψ',
+	"omega":      'ω',
+	"Gamma":      'Γ',
+	"Delta":      'Δ',
+	"Theta":      'Θ',
+	"Lambda":     'Λ',
+	"Xi":         'Ξ',
+	"Pi":         'Π',
+	"Sigma":      'Σ',
+	"Upsilon":    'Υ',
+	"Phi":        'Φ',
+	"Psi":        'Ψ',
+	"Omega":      'Ω',
+}
+
+var operators = map[string]string{
+	// Escaped characters
+	"{": "{",
+	"}": "}",
+	"|": "‖",
+	"%": "%",
+	"$": "$",
+	"&": "&amp;",
+	"#": "#",
+	"_": "_",
+
+	// Binary operators
+	"times":    "×",
+	"div":      "÷",
+	"cdot":     "⋅",
+	"pm":       "±",
+	"mp":       "∓",
+	"ast":      "∗",
+	"circ":     "∘",
+	"bullet":   "∙",
+	"oplus":    "⊕",
+	"otimes":   "⊗",
+	"cup":      "∪",
+	"cap":      "∩",
+	"setminus": "∖",
+	"wedge":    "∧",
+	"land":     "∧",
+	"vee":      "∨",
+	"lor":      "∨",
+	"neg":      "¬",
+	"lnot":     "¬",
+
+	// Relations
+	"leq":            "≤",
+	"le":             "≤",
+	"geq":            "≥",
+	"ge":             "≥",
+	"neq":            "≠",
+	"ne":             "≠",
+	"approx":         "≈",
+	"equiv":          "≡",
+	"sim":            "∼",
+	"simeq":          "≃",
+	"cong":           "≅",
+	"propto":         "∝",
+	"ll":             "≪",
+	"gg":             "≫",
+	"in":             "∈",
+	"notin":          "∉",
+	"ni":             "∋",
+	"subset":         "⊂",
+	"subseteq":       "⊆",
+	"supset":         "⊃",
+	"supseteq":       "⊇",
+	"mid":            "∣",
+	"parallel":       "∥",
+	"perp":           "⊥",
+	"to":             "→",
+	"rightarrow":     "→",
+	"leftarrow":      "←",
+	"gets":           "←",
+	"leftrightarrow": "↔",
+	"Rightarrow":     "⇒",
+	"implies":        "⇒",
+	"Leftarrow":      "⇐",
+	"Leftrightarrow": "⇔",
+	"iff":            "⇔",
+	"mapsto":         "↦",
+
+	// Other symbols
+	"infty":    "∞",
+	"partial":  "∂",
+	"nabla":    "∇",
+	"forall":   "∀",
+	"exists":   "∃",
+	"emptyset": "∅",
+	"ldots":    "…",
+	"cdots":    "⋯",
+	"vdots":    "⋮",
+	"ddots":    "⋱",
+	"prime":    "′",
+	"angle":    "∠",
+	"langle":   "⟨",
+	"rangle":   "⟩",
+	"lfloor":   "⌊",
+	"rfloor":   "⌋",
+	"lceil":    "⌈",
+	"rceil":    "⌉",
+
+	// Large operators
+	"sum":    "∑",
+	"prod":   "∏",
+	"coprod": "∐",
+	"int":    "∫",
+	"iint":   "∬",
+	"oint":   "∮",
+	"bigcup": "⋃",
+	"bigcap": "⋂",
+}
+
+// Operators drawn larger than the text around them
+var large = map[string]bool{
+	"sum":    true,
+	"prod":   true,
+	"coprod": true,
+	"int":    true,
+	"iint":   true,
+	"oint":   true,
+	"bigcup": true,
+	"bigcap": true,
+}
+
+// Commands whose scripts are drawn above and below in display math
+var limits = map[token]bool{
+	`\sum`:    true,
+	`\prod`:   true,
+	`\coprod`: true,
+	`\bigcup`: true,
+	`\bigcap`: true,
+	`\lim`:    true,
+	`\max`:    true,
+	`\min`:    true,
+	`\sup`:    true,
+	`\inf`:    true,
+}
+
+// Named functions, which are set upright rather than as variables
+var functions = map[string]bool{
+	"sin":    true,
+	"cos":    true,
+	"tan":    true,
+	"sec":    true,
+	"csc":    true,
+	"cot":    true,
+	"sinh":   true,
+	"cosh":   true,
+	"tanh":   true,
+	"arcsin": true,
+	"arccos": true,
+	"arctan": true,
+	"log":    true,
+	"ln":     true,
+	"lg":     true,
+	"exp":    true,
+	"lim":    true,
+	"max":    true,
+	"min":    true,
+	"sup":    true,
+	"inf":    true,
+	"det":    true,
+	"gcd":    true,
+	"deg":    true,
+	"dim":    true,
+	"arg":    true,
+	"Pr":     true,
+}
+
+var spaces = map[string]string{
+	",":     "0.167em",
+	":":     "0.222em",
+	">":     "0.222em",
+	";":     "0.278em",
+	"!":     "-0.167em",
+	" ":     "0.333em",
+	"quad":  "1em",
+	"qquad": "2em",
+}
+
+var accents = map[string]string{
+	"hat":       "^",
+	"widehat":   "^",
+	"bar":       "¯",
+	"overline":  "¯",
+	"vec":       "→",
+	"dot":       "˙",
+	"ddot":      "¨",
+	"tilde":     "~",
+	"widetilde": "~",
+}
+
+// Delimiters which may follow \left and \right
+var delimiters = map[token]string{
+	"(":       "(",
+	")":       ")",
+	"[":       "[",
+	"]":       "]",
+	"|":       "|",
+	"/":       "/",
+	`\{`:      "{",
+	`\}`:      "}",
+	`\|`:      "‖",
+	`\langle`: "⟨",
+	`\rangle`: "⟩",
+	`\lfloor`: "⌊",
+	`\rfloor`: "⌋",
+	`\lceil`:  "⌈",
+	`\rceil`:  "⌉",
+	`\lbrace`: "{",
+	`\rbrace`: "}",
+}
+
+// Commands which write their text in another alphabet. Bold and
+// blackboard letters are drawn from Unicode's mathematical alphabets,
+// since browsers don't all support MathML's mathvariant attribute
+var alphabets = map[string]func(string) string{
+	"mathrm":       func(text string) string { return text },
+	"operatorname": func(text string) string { return text },
+	"mathbf": func(text string) string {
+		return strings.Map(func(char rune) rune {
+			switch {
+			case char >= 'A' && char <= 'Z':
+				return '𝐀' + char - 'A'
+			case char >= 'a' && char <= 'z':
+				return '𝐚' + char - 'a'
+			case char >= '0' && char <= '9':
+				return '𝟎' + char - '0'
+			}
+
+			return char
+		}, text)
+	},
+	"mathbb": func(text string) string {
+		return strings.Map(func(char rune) rune {
+			// A few letters were in Unicode before the rest of the alphabet
+			if letter, ok := doubleStruck[char]; ok {
+				return letter
+			}
+
+			switch {
+			case char >= 'A' && char <= 'Z':
+				return '𝔸' + char - 'A'
+			case char >= 'a' && char <= 'z':
+				return '𝕒' + char - 'a'
+			case char >= '0' && char <= '9':
+				return '𝟘' + char - '0'
+			}
+
+			return char
+		}, text)
+	},
+}
+
+var doubleStruck = map[rune]rune{
+	'C': 'ℂ',
+	'H': 'ℍ',
+	'N': 'ℕ',
+	'P': 'ℙ',
+	'Q': 'ℚ',
+	'R': 'ℝ',
+	'Z': 'ℤ',
+}
@@ -0,0 +1,116 @@
+// Package tex finds the formulas written in the text of a block, and
+// converts the commonly used subset of LaTeX math into MathML
+//
+// Inline math is written between single dollar signs ($x^2$), and display
+// math, which sits on a line of its own, between double dollar signs. An
+// opening dollar sign must be followed by something other than a space,
+// and a closing one must follow something other than a space and not be
+// followed by a digit, so that prices like $5 and $10 are left as text.
+// Inline math can't contain a dollar sign, and a backslash before one
+// (\$) always writes a dollar sign.
+package tex
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Segment is either a run of ordinary text or a single formula
+type Segment struct {
+	Text    string
+	Math    bool
+	Display bool
+}
+
+// Split breaks text into its ordinary text and the formulas within it
+func Split(text string) []Segment {
+	segments := make([]Segment, 0, 1)
+	plain := strings.Builder{}
+
+	flush := func() {
+		if plain.Len() > 0 {
+			segments = append(segments, Segment{Text: plain.String()})
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(text[i:], `\$`):
+			plain.WriteByte('$')
+			i += 2
+		case strings.HasPrefix(text[i:], "$$"):
+			end := closingDisplay(text, i+2)
+			if end < 0 {
+				plain.WriteString("$$")
+				i += 2
+				continue
+			}
+
+			flush()
+			segments = append(segments, Segment{Text: strings.TrimSpace(text[i+2 : end]), Math: true, Display: true})
+			i = end + 2
+		case text[i] == '$':
+			end := closingInline(text, i+1)
+			if end < 0 {
+				plain.WriteByte('$')
+				i++
+				continue
+			}
+
+			flush()
+			segments = append(segments, Segment{Text: text[i+1 : end], Math: true})
+			i = end + 1
+		default:
+			plain.WriteByte(text[i])
+			i++
+		}
+	}
+
+	flush()
+
+	return segments
+}
+
+// Find the $$ closing display math which opened just before start
+func closingDisplay(text string, start int) int {
+	for i := start; i < len(text)-1; i++ {
+		if text[i] == '\\' {
+			i++
+		} else if text[i] == '$' && text[i+1] == '$' {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Find the $ closing inline math which opened just before start
+func closingInline(text string, start int) int {
+	if first, _ := utf8.DecodeRuneInString(text[start:]); start >= len(text) || unicode.IsSpace(first) {
+		return -1
+	}
+
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '\n':
+			// Inline math never spans lines
+			return -1
+		case '$':
+			before, _ := utf8.DecodeLastRuneInString(text[:i])
+			after, _ := utf8.DecodeRuneInString(text[i+1:])
+			if i > start && !unicode.IsSpace(before) && !unicode.IsDigit(after) {
+				return i
+			}
+
+			// Formulas can't hold a dollar sign of their own, so this
+			// one opens another formula (or is just a dollar sign)
+			return -1
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,130 @@
+package tex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	segments := Split(`Costs \$5 or $10, so $x^2$ and $$\frac{1}{2}$$`)
+	if len(segments) != 4 {
+		t.Errorf("Expected four segments-- got %+v", segments)
+		return
+	}
+
+	if segments[0].Math || segments[0].Text != "Costs $5 or $10, so " {
+		t.Errorf("Expected prices to be left as text-- got %+v", segments[0])
+		return
+	}
+
+	if !segments[1].Math || segments[1].Display || segments[1].Text != "x^2" {
+		t.Errorf("Expected inline math-- got %+v", segments[1])
+		return
+	}
+
+	if !segments[3].Math || !segments[3].Display || segments[3].Text != `\frac{1}{2}` {
+		t.Errorf("Expected display math-- got %+v", segments[3])
+	}
+}
+
+func TestUnclosedMath(t *testing.T) {
+	texts := []string{
+		"a $ b $ c",
+		"$x\n$",
+		"$$x",
+	}
+
+	for _, text := range texts {
+		if segments := Split(text); len(segments) != 1 || segments[0].Math {
+			t.Errorf("Expected %q to be left as text-- got %+v", text, segments)
+		}
+	}
+}
+
+func TestMathML(t *testing.T) {
+	formulas := map[string]string{
+		`x^2`:                "<msup><mi>x</mi><mn>2</mn></msup>",
+		`a_{i}^{2}`:          "<msubsup><mi>a</mi><mi>i</mi><mn>2</mn></msubsup>",
+		`3.14`:               "<mn>3.14</mn>",
+		`\frac{a}{b}`:        "<mfrac><mi>a</mi><mi>b</mi></mfrac>",
+		`\sqrt[3]{x}`:        "<mroot><mi>x</mi><mn>3</mn></mroot>",
+		`\alpha \leq \Omega`: `<mi>α</mi><mo>≤</mo><mi mathvariant="normal">Ω</mi>`,
+		`\text{if } x`:       "<mtext>if </mtext>",
+		`\mathbb{R}`:         "ℝ",
+		`\sin x`:             "<mi>sin</mi>",
+		`\left( x \right)`:   `<mo stretchy="true">(</mo>`,
+		`\sum_{i=0}^{n} i`:   "<msubsup><mo largeop=\"true\">∑</mo>",
+		`f'(x)`:              "<msup><mi>f</mi><mo>′</mo></msup>",
+		`a - b`:              "<mo>−</mo>",
+		`\vec{v}`:            `<mover accent="true"><mi>v</mi><mo>→</mo></mover>`,
+		`a < b`:              "<mo>&lt;</mo>",
+	}
+
+	for formula, expected := range formulas {
+		math, err := MathML(formula, false)
+		if err != nil {
+			t.Errorf("Expected %q to convert-- got %s", formula, err)
+			continue
+		}
+
+		if !strings.Contains(math, expected) {
+			t.Errorf("Expected %q to contain %s-- got %s", formula, expected, math)
+		}
+	}
+}
+
+func TestDisplayLimits(t *testing.T) {
+	math, err := MathML(`\lim_{x \to 0} x`, true)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !strings.Contains(math, `display="block"`) || !strings.Contains(math, "<munder><mi>lim</mi>") {
+		t.Errorf("Expected limits below in display math-- got %s", math)
+	}
+}
+
+func TestMathErrors(t *testing.T) {
+	formulas := []string{
+		`x^2^3`,
+		`x_1_2`,
+		`\frac{a}`,
+		`{a`,
+		`a}`,
+		`^2`,
+		`\nope`,
+		`\left( x`,
+		`\left< x \right>`,
+		`\text x`,
+	}
+
+	for _, formula := range formulas {
+		if _, err := MathML(formula, false); err == nil {
+			t.Errorf("Expected an error for %q", formula)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	formulas := map[string]string{
+		`e^{i\pi} + 1 = 0`:      "e^(iπ)+1 = 0",
+		`x^2 + y_1`:             "x²+y₁",
+		`\frac{a+b}{2}`:         "(a+b)/2",
+		`\sqrt{x}`:              "√x",
+		`\vec{v}`:               "v⃗",
+		`\text{area} = \pi r^2`: "area = πr²",
+	}
+
+	for formula, expected := range formulas {
+		text, err := Text(formula)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if text != expected {
+			t.Errorf("Expected %q for %q-- got %q", expected, formula, text)
+		}
+	}
+}
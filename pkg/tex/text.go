@@ -0,0 +1,153 @@
+package tex
+
+import (
+	"encoding/xml"
+	"strings"
+	"unicode/utf8"
+)
+
+// Text converts a formula into plain Unicode text (ex: x^2 becomes x²),
+// for output which can't show MathML. Scripts which have no Unicode
+// form are written after a caret or underscore instead
+func Text(formula string) (string, error) {
+	mathML, err := MathML(formula, false)
+	if err != nil {
+		return "", err
+	}
+
+	root := element{}
+	if err := xml.Unmarshal([]byte(mathML), &root); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(root.text()), nil
+}
+
+// An element of the MathML produced for a formula
+type element struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []element  `xml:",any"`
+	Content  string     `xml:",chardata"`
+}
+
+func (e element) attr(name string) string {
+	for _, attr := range e.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+func (e element) child(i int) string {
+	if i >= len(e.Children) {
+		return ""
+	}
+
+	return e.Children[i].text()
+}
+
+func (e element) text() string {
+	switch e.XMLName.Local {
+	case "annotation":
+		return ""
+	case "mi", "mn", "mtext":
+		return e.Content
+	case "mo":
+		if spaced[e.Content] {
+			return " " + e.Content + " "
+		}
+
+		return e.Content
+	case "mspace":
+		return " "
+	case "msup":
+		return e.child(0) + script(e.child(1), superscripts, "^")
+	case "msub", "munder":
+		return e.child(0) + script(e.child(1), subscripts, "_")
+	case "msubsup", "munderover":
+		return e.child(0) + script(e.child(1), subscripts, "_") + script(e.child(2), superscripts, "^")
+	case "mover":
+		if e.attr("accent") == "true" {
+			if mark, ok := combining[strings.TrimSpace(e.child(1))]; ok && utf8.RuneCountInString(e.child(0)) == 1 {
+				return e.child(0) + mark
+			}
+		}
+
+		return e.child(0) + script(e.child(1), superscripts, "^")
+	case "mfrac":
+		if e.attr("linethickness") == "0" {
+			return e.child(0) + " " + e.child(1)
+		}
+
+		return group(e.child(0)) + "/" + group(e.child(1))
+	case "msqrt":
+		return "√" + group(e.child(0))
+	case "mroot":
+		return script(e.child(1), superscripts, "^") + "√" + group(e.child(0))
+	}
+
+	text := strings.Builder{}
+	for _, child := range e.Children {
+		text.WriteString(child.text())
+	}
+
+	return text.String()
+}
+
+// Relations are written with space around them, as they would be typeset
+var spaced = map[string]bool{
+	"=": true, "≠": true, "<": true, ">": true, "≤": true, "≥": true,
+	"≈": true, "≡": true, "→": true, "⇒": true, "⇔": true, "∈": true,
+}
+
+// Accents which have a combining form, to be drawn over a single letter
+var combining = map[string]string{
+	"^": "̂",
+	"¯": "̄",
+	"→": "⃗",
+	"˙": "̇",
+	"¨": "̈",
+	"~": "̃",
+}
+
+// Wrap text in parentheses if it is more than a single symbol or number
+func group(text string) string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= 1 || strings.Trim(text, "0123456789.") == "" {
+		return text
+	}
+
+	return "(" + text + ")"
+}
+
+// Write a script in superscript or subscript characters, falling back to
+// the marker when any of its characters has no such form
+func script(text string, characters map[rune]rune, marker string) string {
+	text = strings.TrimSpace(text)
+
+	converted := strings.Builder{}
+	for _, char := range text {
+		scripted, ok := characters[char]
+		if !ok {
+			return marker + group(text)
+		}
+
+		converted.WriteRune(scripted)
+	}
+
+	return converted.String()
+}
+
+var superscripts = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+	'+': '⁺', '−': '⁻', '=': '⁼', '(': '⁽', ')': '⁾', 'n': 'ⁿ', 'i': 'ⁱ', '′': '′',
+}
+
+var subscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '−': '₋', '=': '₌', '(': '₍', ')': '₎', 'a': 'ₐ', 'e': 'ₑ', 'i': 'ᵢ', 'j': 'ⱼ',
+	'n': 'ₙ', 'o': 'ₒ', 'x': 'ₓ',
+}
@@ -20,18 +20,82 @@ func (j Justification) String() string {
 }
 
 type Show struct {
+	Metadata   Metadata
+	Dimensions Dimensions
+	// Fonts which are shipped along with the show
+	Fonts  []FontFace
 	Slides []Slide
 }
 
 func NewShow() Show {
 	return Show{
-		Slides: make([]Slide, 0, 32),
+		Dimensions: DefaultDimensions(),
+		Slides:     make([]Slide, 0, 32),
 	}
 }
 
+// Metadata describes a show as a document, for use in
+// titles, document properties and search indexing
+type Metadata struct {
+	Title       string
+	Author      string
+	Date        string
+	Description string
+	// A BCP 47 language tag, such as "en" or "pt-BR"
+	Language string
+}
+
+// Dimensions is the size every slide in a show is authored at, in pixels
+//
+// Renderers scale slides to their output (a browser window, a page)
+// while preserving the aspect ratio these dimensions describe
+type Dimensions struct {
+	Width  uint
+	Height uint
+}
+
+// The largest width or height a show may have, in pixels, so
+// that drawing a slide never needs more memory than is sensible
+const MaxDimension = 8192
+
+// The default slide size is a 16:9 canvas
+func DefaultDimensions() Dimensions {
+	return Dimensions{
+		Width:  1280,
+		Height: 720,
+	}
+}
+
+func (d Dimensions) AspectRatio() float64 {
+	return float64(d.Width) / float64(d.Height)
+}
+
+// A FontFace provides the font file for a family, so
+// that it may be embedded into a renderer's output
+type FontFace struct {
+	Family string
+	Path   string
+}
+
 type Slide struct {
-	Background color.Color
-	Blocks     []Block
+	// The background is painted in layers: the color first, then
+	// the gradient (if any) and finally the image (if any) on top
+	Background         color.Color
+	BackgroundGradient *Gradient
+	BackgroundImage    *Image
+
+	// Repeating text displayed along the top and bottom of the slide
+	Header string
+	Footer string
+	// The position of the slide within the show (ex: "3 / 12"),
+	// empty if the slide should not be numbered
+	Number string
+	// Speaker notes, shown to the presenter but never on the slide
+	Notes string
+
+	Blocks []Block
+	// Drawn behind the blocks, in the order they were declared
+	Shapes []Shape
 }
 
 func NewSlide() Slide {
@@ -41,10 +105,254 @@ func NewSlide() Slide {
 	}
 }
 
+type GradientKind int
+
+const (
+	LinearGradient GradientKind = iota
+	RadialGradient
+)
+
+func (g GradientKind) String() string {
+	return []string{
+		"Linear",
+		"Radial",
+	}[g]
+}
+
+// A Gradient blends between its stops, which are spaced evenly
+// from the start of the gradient to its end
+type Gradient struct {
+	Kind GradientKind
+	// The direction a linear gradient travels in, measured in degrees
+	// clockwise from the top of the slide (ex: 90 runs left to right).
+	// Radial gradients always travel outwards from the center
+	Angle uint
+	Stops []color.Color
+}
+
+type ImageFit int
+
+const (
+	// Scale the image to fit entirely within the slide
+	Fit ImageFit = iota
+	// Scale the image to cover the whole slide, cropping the excess
+	Cover
+	// Repeat the image at its natural size
+	Tile
+)
+
+func (f ImageFit) String() string {
+	return []string{
+		"Fit",
+		"Cover",
+		"Tile",
+	}[f]
+}
+
+// An Image refers to a picture on disk, which renderers embed
+// into their output
+type Image struct {
+	Path string
+	Fit  ImageFit
+}
+
 // A Block represents a styled grouping of text
 type Block struct {
 	Words string
 	Style Style
+	Frame Frame
+	// Whether the block was given a frame, rather than being laid
+	// out one after another with the other blocks of its slide
+	Positioned bool
+	// Set when the words are source code rather than prose
+	Code *Code
+	// Set when the words are the rows of a table
+	Table *Table
+	// Set when the words describe a diagram
+	Diagram *Diagram
+	// Set when the block plots data rather than showing words
+	Chart *Chart
+}
+
+// A Table lays out text in rows and columns. Every row
+// has a cell for each column, even if it is empty
+type Table struct {
+	Rows [][]string
+	// Whether the first row labels the columns
+	HeaderRow bool
+	// The alignment of each column, with any columns not listed
+	// following the justification of the block's style
+	ColumnAlignments []Justification
+	// Width in pixels of the lines between cells, or zero for none
+	Border      uint
+	BorderColor color.Color
+	// The fill behind the header row, or nil for none
+	HeaderBackground color.Color
+}
+
+func NewTable() Table {
+	return Table{
+		HeaderRow:   true,
+		Border:      1,
+		BorderColor: color.RGBA{R: 128, G: 128, B: 128, A: 255},
+	}
+}
+
+// The alignment of a column, given the justification of its block
+func (t Table) ColumnAlignment(column int, fallback Justification) Justification {
+	if column < len(t.ColumnAlignments) {
+		return t.ColumnAlignments[column]
+	}
+
+	return fallback
+}
+
+type DiagramKind int
+
+const (
+	// Boxes joined by arrows, arranged in layers
+	Flowchart DiagramKind = iota
+	// Participants exchanging messages over time
+	Sequence
+)
+
+func (d DiagramKind) String() string {
+	return []string{
+		"Flowchart",
+		"Sequence",
+	}[d]
+}
+
+type DiagramDirection int
+
+const (
+	TopToBottom DiagramDirection = iota
+	LeftToRight
+)
+
+func (d DiagramDirection) String() string {
+	return []string{
+		"TopToBottom",
+		"LeftToRight",
+	}[d]
+}
+
+// A Diagram is a graph of labelled nodes, which renderers lay out themselves
+type Diagram struct {
+	Kind DiagramKind
+	// The direction a flowchart flows in
+	Direction DiagramDirection
+	// Nodes in the order they were first mentioned
+	Nodes []DiagramNode
+	Edges []DiagramEdge
+}
+
+type DiagramNode struct {
+	ID    string
+	Label string
+}
+
+type DiagramEdge struct {
+	From   string
+	To     string
+	Label  string
+	Dashed bool
+}
+
+type ChartKind int
+
+const (
+	BarChart ChartKind = iota
+	LineChart
+	PieChart
+)
+
+func (c ChartKind) String() string {
+	return []string{
+		"Bar",
+		"Line",
+		"Pie",
+	}[c]
+}
+
+// A Chart plots one or more series of values against a set of categories.
+// Pie charts only plot the first series, with a slice for each category
+type Chart struct {
+	Kind       ChartKind
+	Categories []string
+	Series     []ChartSeries
+	XLabel     string
+	YLabel     string
+	// The colors of each series (or each slice of a pie), used in turn
+	Colors []color.Color
+}
+
+type ChartSeries struct {
+	Name   string
+	Values []float64
+}
+
+func NewChart() Chart {
+	return Chart{
+		Colors: []color.Color{
+			color.RGBA{R: 0, G: 102, B: 204, A: 255},
+			color.RGBA{R: 230, G: 126, B: 34, A: 255},
+			color.RGBA{R: 39, G: 174, B: 96, A: 255},
+			color.RGBA{R: 192, G: 57, B: 43, A: 255},
+			color.RGBA{R: 142, G: 68, B: 173, A: 255},
+			color.RGBA{R: 127, G: 140, B: 141, A: 255},
+		},
+	}
+}
+
+// The color of the nth series, starting over once every color has been used
+func (c Chart) Color(n int) color.Color {
+	if len(c.Colors) == 0 {
+		return color.Black
+	}
+
+	return c.Colors[n%len(c.Colors)]
+}
+
+type CodeTheme int
+
+const (
+	LightCode CodeTheme = iota
+	DarkCode
+)
+
+func (c CodeTheme) String() string {
+	return []string{
+		"Light",
+		"Dark",
+	}[c]
+}
+
+// Code describes how the source code in a block is displayed.
+// Whitespace in the block's words is significant
+type Code struct {
+	// The language to highlight the code as, or empty for none
+	Language    string
+	LineNumbers bool
+	// Lines to draw attention to, numbered from 1
+	Highlights []LineRange
+	Theme      CodeTheme
+}
+
+// A LineRange includes both its Start and End lines
+type LineRange struct {
+	Start uint
+	End   uint
+}
+
+func (c Code) IsHighlighted(line uint) bool {
+	for _, lines := range c.Highlights {
+		if line >= lines.Start && line <= lines.End {
+			return true
+		}
+	}
+
+	return false
 }
 
 func NewBlock() Block {
@@ -53,17 +361,139 @@ func NewBlock() Block {
 	}
 }
 
+type ShapeKind int
+
+const (
+	Rectangle ShapeKind = iota
+	Ellipse
+	Line
+	Arrow
+)
+
+func (s ShapeKind) String() string {
+	return []string{
+		"Rectangle",
+		"Ellipse",
+		"Line",
+		"Arrow",
+	}[s]
+}
+
+// A Shape is a simple vector drawing on a slide
+type Shape struct {
+	Kind ShapeKind
+	// The bounds of a rectangle or ellipse
+	Frame Frame
+	// The ends of a line or arrow, with arrows pointing towards To
+	From Point
+	To   Point
+
+	// A nil Fill or Stroke is not drawn
+	Fill        color.Color
+	Stroke      color.Color
+	StrokeWidth uint
+}
+
+func NewShape(kind ShapeKind) Shape {
+	return Shape{
+		Kind:        kind,
+		Stroke:      color.Black,
+		StrokeWidth: 2,
+	}
+}
+
+// A Point is a position on a slide, where each value is a percentage of
+// the slide's dimensions with (0, 0) being the top left corner
+type Point struct {
+	X uint
+	Y uint
+}
+
+// A Frame positions an element on its slide. Each value is a percentage
+// of the slide's dimensions, with (0, 0) being the top left corner
+//
+// A zero Width or Height sizes the element to fit its content
+type Frame struct {
+	X      uint
+	Y      uint
+	Width  uint
+	Height uint
+}
+
+func (f Frame) IsZero() bool {
+	return f == Frame{}
+}
+
+type TextTransform int
+
+const (
+	NoTransform TextTransform = iota
+	Uppercase
+	Lowercase
+	Capitalize
+)
+
+func (t TextTransform) String() string {
+	return []string{
+		"None",
+		"Uppercase",
+		"Lowercase",
+		"Capitalize",
+	}[t]
+}
+
+type VerticalAlignment int
+
+const (
+	Top VerticalAlignment = iota
+	Middle
+	Bottom
+)
+
+func (v VerticalAlignment) String() string {
+	return []string{
+		"Top",
+		"Middle",
+		"Bottom",
+	}[v]
+}
+
 type Style struct {
-	Color         color.Color
-	Font          string
+	Color color.Color
+	Font  string
+	// Families to try in turn if Font is unavailable, which
+	// usually ends with a generic family (ex: "sans-serif")
+	FontFallbacks []string
 	Size          uint8
 	Justification Justification
+
+	// From 1 (thinnest) to 1000 (boldest), where 400 is normal and 700 bold
+	Weight        uint16
+	Italic        bool
+	Underline     bool
+	Strikethrough bool
+	// Extra space added between letters, in pixels
+	LetterSpacing float64
+	// The distance between lines as a multiple of the font size,
+	// or zero to use the font's own line spacing
+	LineHeight float64
+	Transform  TextTransform
+	// Where text sits within a block taller than the text itself
+	VerticalAlignment VerticalAlignment
+	// Space between the edges of the block and its text, in pixels
+	Padding uint
+}
+
+// The font families of the style in order of preference
+func (s Style) FontStack() []string {
+	return append([]string{s.Font}, s.FontFallbacks...)
 }
 
 func NewStyle() Style {
 	return Style{
-		Color: color.Black,
-		Font:  "Times New Roman",
-		Size:  12,
+		Color:  color.Black,
+		Font:   "Times New Roman",
+		Size:   12,
+		Weight: 400,
 	}
 }
@@ -1,131 +1,942 @@
 package html
 
 import (
+	"encoding/base64"
 	"fmt"
+	"github.com/mbStavola/slydes/pkg/chart"
+	"github.com/mbStavola/slydes/pkg/css"
+	"github.com/mbStavola/slydes/pkg/diagram"
+	"github.com/mbStavola/slydes/pkg/highlight"
+	"github.com/mbStavola/slydes/pkg/tex"
 	"github.com/mbStavola/slydes/pkg/types"
 	"html/template"
 	"image/color"
+	"io/ioutil"
+	"math"
+	"mime"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 func Render(show types.Show) error {
-	helpers := template.FuncMap{
-		"count": func(slides []types.Slide) int {
-			return len(slides) - 1
-		},
-		"style": func(style types.Style) template.CSS {
-			fontColor := fontColorStyle(style.Color)
-			styleText := fmt.Sprintf(
-				"font-size: %dpx; font-family: %s; text-align: %s; color: %s;",
-				style.Size,
-				style.Font,
-				style.Justification,
-				fontColor,
-			)
-			return template.CSS(styleText)
-		},
-		"color": fontColorStyle,
-	}
-	slideshow, err := template.New("slideshow").Funcs(helpers).Parse(source)
+	slideshow, err := template.New("slideshow").Funcs(helpers()).Parse(source)
 	if err != nil {
 		return err
 	}
 
+	if _, err := slideshow.Parse(slideSource); err != nil {
+		return err
+	}
+
 	return slideshow.Execute(os.Stdout, show)
 }
 
-const source = `
-<div class="show">
-    {{range $i, $slide := .Slides}}
-		<div class="slide hide" id="slide-{{ $i }}" style="background-color: {{ color $slide.Background }};">
-			<div class="content">
-				{{range $j, $block := $slide.Blocks}}
-					<div class="block" id="slide-{{ $i }}-block-{{ $j }}" style="{{ style $block.Style }}">
-						<span>{{ $block.Words }}</span>
-					</div>
-				{{end}}
-			</div>
-        </div>
-    {{end}}
-</div>
+func helpers() template.FuncMap {
+	return template.FuncMap{
+		"style":      blockStyle,
+		"color":      fontColorStyle,
+		"frame":      frameStyle,
+		"background": backgroundStyle,
+		"fontFace":   fontFaceRule,
+		"code":       codeHTML,
+		"table":      tableHTML,
+		"diagram":    diagramSVG,
+		"chart":      chartSVG,
+		"words":      wordsHTML,
+		"shapes":     shapesSVG,
+		"thumbnail": func(dimensions types.Dimensions) thumbnail {
+			scale := thumbnailWidth / float64(dimensions.Width)
+			return thumbnail{
+				Width:  thumbnailWidth,
+				Height: float64(dimensions.Height) * scale,
+				Scale:  scale,
+			}
+		},
+		"slideContext": func(index int, slide types.Slide, dimensions types.Dimensions) slideContext {
+			return slideContext{Index: index, Slide: slide, Dimensions: dimensions}
+		},
+	}
+}
+
+// What the template for the content of a slide is given
+type slideContext struct {
+	Index      int
+	Slide      types.Slide
+	Dimensions types.Dimensions
+}
 
+// Width in pixels of a slide while in the overview grid
+const thumbnailWidth = 320.0
+
+type thumbnail struct {
+	Width  float64
+	Height float64
+	Scale  float64
+}
+
+const source = `<!DOCTYPE html>
+<html{{ with .Metadata.Language }} lang="{{ . }}"{{ end }}>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="Slydes">
+{{- with .Metadata.Title }}
+<title>{{ . }}</title>
+{{- end }}
+{{- with .Metadata.Author }}
+<meta name="author" content="{{ . }}">
+{{- end }}
+{{- with .Metadata.Description }}
+<meta name="description" content="{{ . }}">
+{{- end }}
+{{- with .Metadata.Date }}
+<meta name="date" content="{{ . }}">
+{{- end }}
+{{ $thumbnail := thumbnail .Dimensions }}
 <style>
 	body, html {
 		margin: 0;
 		padding: 0;
+		width: 100%;
+		height: 100%;
+		overflow: hidden;
+		background-color: black;
 	}
 
-	.slide {
+	.show {
 		width: 100%;
 		height: 100%;
 	}
 
-	.content {
-		margin: auto;
-		width: 90%;
-		height: 90%;
-		padding: 2em;
+	{{- range .Fonts }}
+	{{ fontFace . }}
+	{{- end }}
+
+	/* Slides are laid out at their authored size and scaled to
+	   fit the window, leaving the remainder letterboxed */
+	.frame {
+		position: absolute;
+		top: 0;
+		left: 0;
+		width: 100%;
+		height: 100%;
+		overflow: hidden;
 	}
 
-	.block > * {
-		white-space: pre-line;
+	.slide {
+		position: absolute;
+		top: 50%;
+		left: 50%;
+		width: {{ .Dimensions.Width }}px;
+		height: {{ .Dimensions.Height }}px;
+		margin-left: calc({{ .Dimensions.Width }}px / -2);
+		margin-top: calc({{ .Dimensions.Height }}px / -2);
+		transform: scale(var(--scale, 1));
+		overflow: hidden;
 	}
 
+	{{- template "content-style" }}
+
 	.hide {
 		display: none;
 	}
+
+	/* Speaker notes sit over the bottom of the window when shown */
+	.notes {
+		display: none;
+		position: absolute;
+		left: 0;
+		right: 0;
+		bottom: 4px;
+		max-height: 30%;
+		overflow-y: auto;
+		box-sizing: border-box;
+		padding: 1em 1.5em;
+		background-color: rgba(0, 0, 0, 0.85);
+		color: white;
+		font-family: sans-serif;
+		font-size: 18px;
+		white-space: pre-line;
+	}
+
+	.show.with-notes .notes {
+		display: block;
+	}
+
+	.overview .notes {
+		display: none !important;
+	}
+
+	.progress {
+		position: fixed;
+		left: 0;
+		bottom: 0;
+		width: 100%;
+		height: 4px;
+	}
+
+	.progress-bar {
+		width: 0;
+		height: 100%;
+		background-color: rgba(128, 128, 128, 0.8);
+		transition: width 0.2s ease;
+	}
+
+	.overview {
+		display: grid;
+		grid-template-columns: repeat(auto-fill, {{ $thumbnail.Width }}px);
+		grid-auto-rows: {{ $thumbnail.Height }}px;
+		grid-gap: 1em;
+		justify-content: center;
+		box-sizing: border-box;
+		padding: 1em;
+		overflow-y: auto;
+	}
+
+	.overview .frame {
+		display: block;
+		position: relative;
+		cursor: pointer;
+		outline: 1px solid rgba(128, 128, 128, 0.5);
+	}
+
+	.overview .frame.current {
+		outline: 3px solid rgba(66, 133, 244, 0.9);
+	}
+
+	.overview .slide {
+		top: 0;
+		left: 0;
+		margin: 0;
+		transform: scale({{ $thumbnail.Scale }});
+		transform-origin: top left;
+	}
 </style>
+</head>
+<body>
+<div class="show" id="show">
+    {{range $i, $slide := .Slides}}
+		<div class="frame hide" id="frame-{{ $i }}">
+			<div class="slide" id="slide-{{ $i }}" style="{{ background $slide }}">
+				{{ template "slide" (slideContext $i $slide $.Dimensions) }}
+			</div>
+			{{ with $slide.Notes }}<aside class="notes">{{ . }}</aside>{{ end }}
+        </div>
+    {{end}}
+</div>
+
+<div class="progress"><div class="progress-bar" id="progress-bar"></div></div>
+
 
 <script>
-	// Show title slide by default
-	var titleSlide = document.getElementById('slide-0');
-	titleSlide.className = titleSlide.className.replace(/hide/g, '');
+	var slideCount = {{ len .Slides }};
+	var currentSlide = 0;
+	var inOverview = false;
+
+	// Digits typed before Enter, used to jump to a specific slide
+	var pendingNumber = "";
+
+	function slideElement(i) {
+		return document.getElementById('frame-' + i);
+	}
 
 	function hide(i) {
-		var slide = document.getElementById('slide-' + i);
+		var slide = slideElement(i);
 		slide.className += ' hide';
+		slide.className = slide.className.replace(/ ?current/g, '');
 	}
 
 	function show(i) {
-		var slide = document.getElementById('slide-' + i);
-		slide.className = slide.className.replace(/hide/g, '');
+		var slide = slideElement(i);
+		slide.className = slide.className.replace(/ ?hide/g, '') + ' current';
 	}
 
-	var currentSlide = 0;
+	// Slides are numbered from 1 in the URL so links read the same as the
+	// slide counter, e.g. #slide-5 is the fifth slide
+	function slideFromHash() {
+		var match = /^#slide-(\d+)$/.exec(window.location.hash);
+		if (match === null) {
+			return 0;
+		}
+
+		return parseInt(match[1], 10) - 1;
+	}
 
-	// Handle keypress left
-	document.addEventListener("keydown", function(event) {
-		if (currentSlide === 0 || event.keyCode !== 37) {
+	function goTo(i) {
+		if (isNaN(i) || slideCount === 0) {
 			return;
 		}
 
+		i = Math.max(0, Math.min(slideCount - 1, i));
+
 		hide(currentSlide);
-		currentSlide -= 1;
+		currentSlide = i;
 		show(currentSlide);
+
+		var hash = '#slide-' + (currentSlide + 1);
+		if (window.location.hash !== hash) {
+			history.replaceState(null, '', hash);
+		}
+
+		var progress = slideCount > 1 ? currentSlide / (slideCount - 1) : 1;
+		document.getElementById('progress-bar').style.width = (progress * 100) + '%';
+	}
+
+	function next() {
+		goTo(currentSlide + 1);
+	}
+
+	function previous() {
+		goTo(currentSlide - 1);
+	}
+
+	function toggleOverview() {
+		var showElement = document.getElementById('show');
+		inOverview = !inOverview;
+
+		if (inOverview) {
+			showElement.className += ' overview';
+		} else {
+			showElement.className = showElement.className.replace(/ ?overview/g, '');
+			slideElement(currentSlide).scrollIntoView();
+		}
+	}
+
+	function toggleNotes() {
+		var showElement = document.getElementById('show');
+		if (/ ?with-notes/.test(showElement.className)) {
+			showElement.className = showElement.className.replace(/ ?with-notes/g, '');
+		} else {
+			showElement.className += ' with-notes';
+		}
+	}
+
+	document.addEventListener('keydown', function(event) {
+		if (event.altKey || event.ctrlKey || event.metaKey) {
+			return;
+		}
+
+		if (/^[0-9]$/.test(event.key)) {
+			pendingNumber += event.key;
+			return;
+		}
+
+		switch (event.key) {
+		case 'Enter':
+			if (pendingNumber !== '') {
+				goTo(parseInt(pendingNumber, 10) - 1);
+			} else if (inOverview) {
+				toggleOverview();
+			}
+			break;
+		case 'ArrowRight':
+		case 'ArrowDown':
+		case 'PageDown':
+			next();
+			break;
+		case 'ArrowLeft':
+		case 'ArrowUp':
+		case 'PageUp':
+			previous();
+			break;
+		case ' ':
+			if (event.shiftKey) {
+				previous();
+			} else {
+				next();
+			}
+			break;
+		case 'Home':
+			goTo(0);
+			break;
+		case 'End':
+			goTo(slideCount - 1);
+			break;
+		case 'o':
+		case 'O':
+			toggleOverview();
+			break;
+		case 'n':
+		case 'N':
+			toggleNotes();
+			break;
+		case 'Escape':
+			if (inOverview) {
+				toggleOverview();
+			}
+			break;
+		default:
+			pendingNumber = '';
+			return;
+		}
+
+		pendingNumber = '';
+		event.preventDefault();
 	});
 
-	// Handle keypress right
-	document.addEventListener("keydown", function(event) {
-		if (currentSlide === {{ count .Slides }} || event.keyCode !== 39) {
+	// Clicking advances the show, or picks a slide while in the overview
+	document.addEventListener('click', function(event) {
+		if (!inOverview) {
+			next();
 			return;
 		}
 
-		hide(currentSlide);
-		currentSlide += 1;
-		show(currentSlide);
+		for (var i = 0; i < slideCount; i++) {
+			if (slideElement(i).contains(event.target)) {
+				goTo(i);
+				toggleOverview();
+				return;
+			}
+		}
 	});
+
+	// Horizontal swipes move between slides on touch screens
+	var touchStartX = null;
+	var touchStartY = null;
+
+	document.addEventListener('touchstart', function(event) {
+		touchStartX = event.changedTouches[0].clientX;
+		touchStartY = event.changedTouches[0].clientY;
+	});
+
+	document.addEventListener('touchend', function(event) {
+		if (touchStartX === null || inOverview) {
+			return;
+		}
+
+		var deltaX = event.changedTouches[0].clientX - touchStartX;
+		var deltaY = event.changedTouches[0].clientY - touchStartY;
+		touchStartX = null;
+
+		if (Math.abs(deltaX) < 50 || Math.abs(deltaX) < Math.abs(deltaY)) {
+			return;
+		}
+
+		// Swallow the click that follows the touch
+		event.preventDefault();
+
+		if (deltaX < 0) {
+			next();
+		} else {
+			previous();
+		}
+	});
+
+	// Scale the authored slide size to the largest that fits the window
+	function fit() {
+		var scale = Math.min(
+			window.innerWidth / {{ .Dimensions.Width }},
+			window.innerHeight / {{ .Dimensions.Height }}
+		);
+		document.documentElement.style.setProperty('--scale', scale);
+	}
+
+	window.addEventListener('resize', fit);
+	fit();
+
+	window.addEventListener('hashchange', function() {
+		goTo(slideFromHash());
+	});
+
+	// Show the slide named in the URL, defaulting to the title slide
+	goTo(slideFromHash());
 </script>
+</body>
+</html>
 `
 
-func fontColorStyle(color color.Color) template.CSS {
-	r, g, b, a := color.RGBA()
-	convert := func(x uint32) uint8 {
-		return uint8((float32(x) / 65535.0) * 255.0)
+// The markup and styles of what is on a slide, shared by the built-in
+// player and the reveal.js page
+const slideSource = `{{ define "content-style" }}
+
+	/* Shapes are drawn behind the text of the slide */
+	.shapes {
+		position: absolute;
+		top: 0;
+		left: 0;
+		width: 100%;
+		height: 100%;
+		z-index: -1;
+	}
+
+	.content {
+		margin: auto;
+		width: 90%;
+		height: 90%;
+		padding: 2em;
+	}
+
+	.block > * {
+		white-space: pre-line;
+	}
+
+	.block > .code {
+		margin: 0;
+		padding: 0.5em 0;
+		font: inherit;
+		white-space: pre;
+		tab-size: 4;
+		border-radius: 4px;
+		overflow: hidden;
+	}
+
+	.code .line {
+		display: block;
+		padding: 0 1em;
 	}
 
-	// Place rgba values in a range of 0 to 255
-	r2, g2, b2, a2 := convert(r), convert(g), convert(b), convert(a)
-	colorStyle := fmt.Sprintf("rgba(%d, %d, %d, %d)", r2, g2, b2, a2)
+	.code .line-number {
+		display: inline-block;
+		min-width: 2em;
+		margin-right: 1em;
+		text-align: right;
+		user-select: none;
+	}
+
+	.block > .table {
+		border-collapse: collapse;
+		width: 100%;
+		font: inherit;
+		color: inherit;
+	}
+
+	.table th, .table td {
+		padding: 0.25em 0.5em;
+	}
+
+	.block > .chart {
+		display: block;
+	}
+
+	.block > .diagram {
+		display: block;
+		max-width: 100%;
+		max-height: 100%;
+		margin: auto;
+	}
+
+	.decoration {
+		position: absolute;
+		font-family: sans-serif;
+		font-size: 16px;
+		color: rgba(128, 128, 128, 0.9);
+	}
+
+	.header {
+		top: 16px;
+		left: 24px;
+		right: 24px;
+		text-align: center;
+	}
+
+	.footer {
+		bottom: 16px;
+		left: 24px;
+	}
+
+	.page-number {
+		bottom: 16px;
+		right: 24px;
+	}
+{{- end }}
+
+{{ define "slide" }}
+{{- $i := .Index }}{{ $slide := .Slide -}}
+		{{ with $slide.Shapes }}{{ shapes $i . $.Dimensions }}{{ end }}
+		<div class="content">
+			{{range $j, $block := $slide.Blocks}}
+				<div class="block" id="slide-{{ $i }}-block-{{ $j }}" style="{{ style $block.Style }} {{ frame $block }}">
+					{{- if $block.Code }}
+						{{ code $block }}
+					{{- else if $block.Table }}
+						{{ table $block }}
+					{{- else if $block.Diagram }}
+						{{ diagram $i $j $block }}
+					{{- else if $block.Chart }}
+						{{ chart $i $j $block $.Dimensions }}
+					{{- else }}
+						<span>{{ words $block.Words }}</span>
+					{{- end }}
+				</div>
+			{{end}}
+		</div>
+		{{ with $slide.Header }}<div class="decoration header">{{ . }}</div>{{ end }}
+		{{ with $slide.Footer }}<div class="decoration footer">{{ . }}</div>{{ end }}
+		{{ with $slide.Number }}<div class="decoration page-number">{{ . }}</div>{{ end }}
+{{- end }}`
+
+func blockStyle(style types.Style) template.CSS {
+	fontColor := fontColorStyle(style.Color)
+	styleText := fmt.Sprintf(
+		"font-size: %dpx; font-family: %s; text-align: %s; color: %s;",
+		style.Size,
+		css.FontFamilies(style.FontStack()),
+		style.Justification,
+		fontColor,
+	)
+
+	// Everything else is only emitted when it differs from the browser's default
+	if style.Weight != 0 && style.Weight != 400 {
+		styleText += fmt.Sprintf(" font-weight: %d;", style.Weight)
+	}
+	if style.Italic {
+		styleText += " font-style: italic;"
+	}
+
+	decorations := make([]string, 0, 2)
+	if style.Underline {
+		decorations = append(decorations, "underline")
+	}
+	if style.Strikethrough {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		styleText += fmt.Sprintf(" text-decoration: %s;", strings.Join(decorations, " "))
+	}
+
+	if style.LetterSpacing != 0 {
+		styleText += fmt.Sprintf(" letter-spacing: %spx;", strconv.FormatFloat(style.LetterSpacing, 'f', -1, 64))
+	}
+	if style.LineHeight != 0 {
+		styleText += fmt.Sprintf(" line-height: %s;", strconv.FormatFloat(style.LineHeight, 'f', -1, 64))
+	}
+	if style.Transform != types.NoTransform {
+		styleText += fmt.Sprintf(" text-transform: %s;", strings.ToLower(style.Transform.String()))
+	}
+	if style.Padding != 0 {
+		styleText += fmt.Sprintf(" padding: %dpx;", style.Padding)
+	}
+
+	// Stacking the text in a column lets it be pushed to the middle or bottom
+	switch style.VerticalAlignment {
+	case types.Middle:
+		styleText += " display: flex; flex-direction: column; justify-content: center;"
+	case types.Bottom:
+		styleText += " display: flex; flex-direction: column; justify-content: flex-end;"
+	}
+
+	return template.CSS(styleText)
+}
+
+// Render the source in a code block line by line, so that
+// individual lines can be numbered and highlighted
+func codeHTML(block types.Block) template.HTML {
+	palette := highlight.LightPalette
+	if block.Code.Theme == types.DarkCode {
+		palette = highlight.DarkPalette
+	}
+
+	code := strings.Builder{}
+	fmt.Fprintf(
+		&code,
+		`<pre class="code" style="background-color: %s; color: %s;"><code>`,
+		fontColorStyle(palette.Background),
+		fontColorStyle(palette.Color(highlight.Plain)),
+	)
+
+	for i, line := range highlight.Highlight(block.Code.Language, block.Words) {
+		number := uint(i + 1)
+		if block.Code.IsHighlighted(number) {
+			fmt.Fprintf(&code, `<span class="line highlighted" style="background-color: %s;">`, fontColorStyle(palette.Highlight))
+		} else {
+			code.WriteString(`<span class="line">`)
+		}
+
+		if block.Code.LineNumbers {
+			fmt.Fprintf(&code, `<span class="line-number" style="color: %s;">%d</span>`, fontColorStyle(palette.LineNumber), number)
+		}
+
+		for _, span := range line {
+			text := template.HTMLEscapeString(span.Text)
+			if span.Kind == highlight.Plain {
+				code.WriteString(text)
+			} else {
+				fmt.Fprintf(&code, `<span style="color: %s;">%s</span>`, fontColorStyle(palette.Color(span.Kind)), text)
+			}
+		}
+
+		code.WriteString("\n</span>")
+	}
+
+	code.WriteString("</code></pre>")
+
+	return template.HTML(code.String())
+}
+
+func tableHTML(block types.Block) template.HTML {
+	table := block.Table
+
+	border := "none"
+	if table.Border != 0 {
+		border = fmt.Sprintf("%dpx solid %s", table.Border, fontColorStyle(table.BorderColor))
+	}
+
+	cell := func(html *strings.Builder, tag string, column int, text string) {
+		alignment := table.ColumnAlignment(column, block.Style.Justification)
+		fmt.Fprintf(
+			html,
+			`<%s style="border: %s; text-align: %s;">%s</%s>`,
+			tag,
+			border,
+			strings.ToLower(alignment.String()),
+			template.HTMLEscapeString(text),
+			tag,
+		)
+	}
+
+	html := strings.Builder{}
+	html.WriteString(`<table class="table">`)
+
+	rows := table.Rows
+	if table.HeaderRow && len(rows) > 0 {
+		if table.HeaderBackground != nil {
+			fmt.Fprintf(&html, `<thead style="background-color: %s;"><tr>`, fontColorStyle(table.HeaderBackground))
+		} else {
+			html.WriteString("<thead><tr>")
+		}
+
+		for i, text := range rows[0] {
+			cell(&html, "th", i, text)
+		}
+
+		html.WriteString("</tr></thead>")
+		rows = rows[1:]
+	}
+
+	html.WriteString("<tbody>")
+	for _, row := range rows {
+		html.WriteString("<tr>")
+		for i, text := range row {
+			cell(&html, "td", i, text)
+		}
+		html.WriteString("</tr>")
+	}
+	html.WriteString("</tbody></table>")
+
+	return template.HTML(html.String())
+}
+
+// Escape the text of a block, converting any formulas within it to MathML
+func wordsHTML(words string) (template.HTML, error) {
+	text := strings.Builder{}
+	for _, segment := range tex.Split(words) {
+		if !segment.Math {
+			text.WriteString(template.HTMLEscapeString(segment.Text))
+			continue
+		}
+
+		math, err := tex.MathML(segment.Text, segment.Display)
+		if err != nil {
+			return "", err
+		}
+
+		text.WriteString(math)
+	}
+
+	return template.HTML(text.String()), nil
+}
+
+// Draw a chart to fill its block, or at a reasonable size for blocks
+// which are sized to fit their content
+func chartSVG(slideIndex int, blockIndex int, block types.Block, dimensions types.Dimensions) template.HTML {
+	width := float64(dimensions.Width) * 0.6
+	if block.Frame.Width != 0 {
+		width = float64(dimensions.Width) * float64(block.Frame.Width) / 100
+	}
+
+	height := width * 0.6
+	if block.Frame.Height != 0 {
+		height = float64(dimensions.Height) * float64(block.Frame.Height) / 100
+	}
+
+	padding := 2 * float64(block.Style.Padding)
+	width, height = math.Max(0, width-padding), math.Max(0, height-padding)
+
+	id := fmt.Sprintf("slide-%d-block-%d-chart", slideIndex, blockIndex)
+	return template.HTML(chart.SVG(*block.Chart, id, width, height, float64(block.Style.Size), block.Style.Color))
+}
+
+// Lay out a diagram block as an inline svg. Text in the diagram inherits
+// the block's font, so only the size and color are passed along
+func diagramSVG(slideIndex int, blockIndex int, block types.Block) template.HTML {
+	size := float64(block.Style.Size)
+	id := fmt.Sprintf("slide-%d-block-%d-diagram", slideIndex, blockIndex)
+
+	return template.HTML(diagram.Arrange(*block.Diagram, size).SVG(id, size, "", block.Style.Color))
+}
+
+// Draw the shapes of a slide into an SVG the size of the slide, converting
+// their percentages into pixels so that stroke widths are not stretched
+func shapesSVG(slideIndex int, shapes []types.Shape, dimensions types.Dimensions) template.HTML {
+	x := func(percentage uint) float64 {
+		return float64(percentage) / 100 * float64(dimensions.Width)
+	}
+	y := func(percentage uint) float64 {
+		return float64(percentage) / 100 * float64(dimensions.Height)
+	}
+	paint := func(c color.Color) string {
+		if c == nil {
+			return "none"
+		}
+
+		return string(fontColorStyle(c))
+	}
+
+	svg := strings.Builder{}
+	fmt.Fprintf(
+		&svg,
+		`<svg class="shapes" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" aria-hidden="true">`,
+		dimensions.Width,
+		dimensions.Height,
+	)
+
+	for j, shape := range shapes {
+		stroke := fmt.Sprintf(`stroke="%s" stroke-width="%d"`, paint(shape.Stroke), shape.StrokeWidth)
+
+		switch shape.Kind {
+		case types.Rectangle:
+			fmt.Fprintf(
+				&svg,
+				`<rect x="%g" y="%g" width="%g" height="%g" fill="%s" %s/>`,
+				x(shape.Frame.X), y(shape.Frame.Y), x(shape.Frame.Width), y(shape.Frame.Height),
+				paint(shape.Fill), stroke,
+			)
+		case types.Ellipse:
+			rx, ry := x(shape.Frame.Width)/2, y(shape.Frame.Height)/2
+			fmt.Fprintf(
+				&svg,
+				`<ellipse cx="%g" cy="%g" rx="%g" ry="%g" fill="%s" %s/>`,
+				x(shape.Frame.X)+rx, y(shape.Frame.Y)+ry, rx, ry,
+				paint(shape.Fill), stroke,
+			)
+		case types.Line, types.Arrow:
+			marker := ""
+			if shape.Kind == types.Arrow {
+				// Each arrow gets its own head so that it matches the arrow's color
+				id := fmt.Sprintf("arrow-%d-%d", slideIndex, j)
+				fmt.Fprintf(
+					&svg,
+					`<defs><marker id="%s" viewBox="0 0 10 10" refX="9" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="%s"/></marker></defs>`,
+					id, paint(shape.Stroke),
+				)
+				marker = fmt.Sprintf(` marker-end="url(#%s)"`, id)
+			}
+
+			fmt.Fprintf(
+				&svg,
+				`<line x1="%g" y1="%g" x2="%g" y2="%g" %s%s/>`,
+				x(shape.From.X), y(shape.From.Y), x(shape.To.X), y(shape.To.Y),
+				stroke, marker,
+			)
+		}
+	}
+
+	svg.WriteString("</svg>")
+
+	return template.HTML(svg.String())
+}
+
+func fontFaceRule(font types.FontFace) (template.CSS, error) {
+	rule, err := css.FontFace(font)
+	return template.CSS(rule), err
+}
+
+func frameStyle(block types.Block) template.CSS {
+	if !block.Positioned {
+		return ""
+	}
+
+	frame := block.Frame
+
+	styleText := fmt.Sprintf("position: absolute; left: %d%%; top: %d%%;", frame.X, frame.Y)
+	if frame.Width != 0 {
+		styleText += fmt.Sprintf(" width: %d%%;", frame.Width)
+	}
+	if frame.Height != 0 {
+		styleText += fmt.Sprintf(" height: %d%%;", frame.Height)
+	}
+
+	return template.CSS(styleText)
+}
+
+// Layer the slide's background image over its gradient, over its color
+func backgroundStyle(slide types.Slide) (template.CSS, error) {
+	styleText := fmt.Sprintf("background-color: %s;", fontColorStyle(slide.Background))
+
+	images := make([]string, 0, 2)
+	sizes := make([]string, 0, 2)
+	repeats := make([]string, 0, 2)
+
+	if image := slide.BackgroundImage; image != nil {
+		url, err := dataURL(image.Path)
+		if err != nil {
+			return "", err
+		}
+
+		images = append(images, fmt.Sprintf("url(\"%s\")", url))
+
+		switch image.Fit {
+		case types.Fit:
+			sizes = append(sizes, "contain")
+			repeats = append(repeats, "no-repeat")
+		case types.Cover:
+			sizes = append(sizes, "cover")
+			repeats = append(repeats, "no-repeat")
+		case types.Tile:
+			sizes = append(sizes, "auto")
+			repeats = append(repeats, "repeat")
+		}
+	}
+
+	if gradient := slide.BackgroundGradient; gradient != nil {
+		images = append(images, string(gradientStyle(*gradient)))
+		sizes = append(sizes, "100% 100%")
+		repeats = append(repeats, "no-repeat")
+	}
+
+	if len(images) > 0 {
+		styleText += fmt.Sprintf(
+			" background-image: %s; background-size: %s; background-repeat: %s; background-position: center;",
+			strings.Join(images, ", "),
+			strings.Join(sizes, ", "),
+			strings.Join(repeats, ", "),
+		)
+	}
+
+	return template.CSS(styleText), nil
+}
+
+func gradientStyle(gradient types.Gradient) template.CSS {
+	stops := make([]string, len(gradient.Stops))
+	for i, stop := range gradient.Stops {
+		stops[i] = string(fontColorStyle(stop))
+	}
+
+	if gradient.Kind == types.RadialGradient {
+		return template.CSS(fmt.Sprintf("radial-gradient(circle, %s)", strings.Join(stops, ", ")))
+	}
+
+	return template.CSS(fmt.Sprintf("linear-gradient(%ddeg, %s)", gradient.Angle, strings.Join(stops, ", ")))
+}
+
+// Inline a file so that the generated page is self-contained
+func dataURL(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	mediaType := mime.TypeByExtension(filepath.Ext(path))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+	}
+
+	return fmt.Sprintf("data:%s;base64,%s", mediaType, base64.StdEncoding.EncodeToString(data)), nil
+}
 
-	return template.CSS(colorStyle)
+func fontColorStyle(c color.Color) template.CSS {
+	return template.CSS(css.RGBA(c))
 }
@@ -0,0 +1,189 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// The release of reveal.js linked to when no copy is given to inline
+const revealVersion = "5.1.0"
+
+// The files of reveal.js which a page needs, relative to the root of a
+// copy of it, as published to npm or built from its repository
+var revealFiles = map[string]string{
+	"reset":  "dist/reset.css",
+	"styles": "dist/reveal.css",
+	"script": "dist/reveal.js",
+	"notes":  "plugin/notes/notes.js",
+}
+
+// RenderReveal writes the show as a reveal.js page, with each slide as a
+// section, so that it can be presented with reveal.js and its plugins.
+// Given the directory of a copy of reveal.js, its files are inlined into
+// the page so that it works offline. Otherwise they are linked from a CDN
+func RenderReveal(show types.Show, library string) error {
+	funcs := helpers()
+	funcs["revealBackground"] = revealBackground
+	funcs["revealStyles"] = func() (template.HTML, error) {
+		return revealInclude(library, "reset", "styles")
+	}
+	funcs["revealScripts"] = func() (template.HTML, error) {
+		return revealInclude(library, "script", "notes")
+	}
+
+	page, err := template.New("reveal").Funcs(funcs).Parse(revealSource)
+	if err != nil {
+		return err
+	}
+
+	if _, err := page.Parse(slideSource); err != nil {
+		return err
+	}
+
+	return page.Execute(os.Stdout, show)
+}
+
+// Link to the files, or inline them when there is a copy of the library
+func revealInclude(library string, names ...string) (template.HTML, error) {
+	include := strings.Builder{}
+	for _, name := range names {
+		file := revealFiles[name]
+		stylesheet := strings.HasSuffix(file, ".css")
+
+		if library == "" {
+			url := template.HTMLEscapeString(fmt.Sprintf("https://cdn.jsdelivr.net/npm/reveal.js@%s/%s", revealVersion, file))
+			if stylesheet {
+				fmt.Fprintf(&include, "<link rel=\"stylesheet\" href=\"%s\">\n", url)
+			} else {
+				fmt.Fprintf(&include, "<script src=\"%s\"></script>\n", url)
+			}
+
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(library, filepath.FromSlash(file)))
+		if err != nil {
+			return "", fmt.Errorf("can't inline reveal.js: %w", err)
+		}
+
+		// Nothing in the file may close the element it is inlined into early
+		if stylesheet {
+			text := strings.ReplaceAll(string(data), "</style", `<\/style`)
+			fmt.Fprintf(&include, "<style>\n%s\n</style>\n", text)
+		} else {
+			text := strings.ReplaceAll(string(data), "</script", `<\/script`)
+			fmt.Fprintf(&include, "<script>\n%s\n</script>\n", text)
+		}
+	}
+
+	return template.HTML(include.String()), nil
+}
+
+// Backgrounds are handed to reveal.js, so that they fill the window
+// rather than just the slide. It draws a background image in place of
+// a gradient, rather than over it
+func revealBackground(slide types.Slide) (template.HTMLAttr, error) {
+	attributes := make([]string, 0, 4)
+	if slide.Background != nil {
+		attributes = append(attributes, fmt.Sprintf(`data-background-color="%s"`, fontColorStyle(slide.Background)))
+	}
+
+	if gradient := slide.BackgroundGradient; gradient != nil {
+		attributes = append(attributes, fmt.Sprintf(`data-background-gradient="%s"`, gradientStyle(*gradient)))
+	}
+
+	if image := slide.BackgroundImage; image != nil {
+		url, err := dataURL(image.Path)
+		if err != nil {
+			return "", err
+		}
+
+		size, repeat := "contain", "no-repeat"
+		switch image.Fit {
+		case types.Cover:
+			size = "cover"
+		case types.Tile:
+			size, repeat = "auto", "repeat"
+		}
+
+		attributes = append(
+			attributes,
+			fmt.Sprintf(`data-background-image="%s"`, url),
+			fmt.Sprintf(`data-background-size="%s"`, size),
+			fmt.Sprintf(`data-background-repeat="%s"`, repeat),
+		)
+	}
+
+	return template.HTMLAttr(strings.Join(attributes, " ")), nil
+}
+
+const revealSource = `<!DOCTYPE html>
+<html{{ with .Metadata.Language }} lang="{{ . }}"{{ end }}>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="Slydes">
+{{- with .Metadata.Title }}
+<title>{{ . }}</title>
+{{- end }}
+{{- with .Metadata.Author }}
+<meta name="author" content="{{ . }}">
+{{- end }}
+{{- with .Metadata.Description }}
+<meta name="description" content="{{ . }}">
+{{- end }}
+{{- with .Metadata.Date }}
+<meta name="date" content="{{ . }}">
+{{- end }}
+{{ revealStyles }}
+<style>
+	{{- range .Fonts }}
+	{{ fontFace . }}
+	{{- end }}
+
+	/* Slides are laid out by Slydes rather than reveal.js, from the
+	   top left corner of the slide */
+	.reveal .slides {
+		text-align: left;
+	}
+
+	.reveal .slides > section {
+		width: {{ .Dimensions.Width }}px;
+		height: {{ .Dimensions.Height }}px;
+		padding: 0;
+		overflow: hidden;
+	}
+	{{- template "content-style" }}
+</style>
+</head>
+<body>
+<div class="reveal">
+	<div class="slides">
+	{{- range $i, $slide := .Slides }}
+		<section {{ revealBackground $slide }}>
+			{{ template "slide" (slideContext $i $slide $.Dimensions) }}
+			{{ with $slide.Notes }}<aside class="notes">{{ . }}</aside>{{ end }}
+		</section>
+	{{- end }}
+	</div>
+</div>
+{{ revealScripts }}
+<script>
+	Reveal.initialize({
+		width: {{ .Dimensions.Width }},
+		height: {{ .Dimensions.Height }},
+		margin: 0,
+		center: false,
+		hash: true,
+		plugins: [RevealNotes]
+	});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,501 @@
+package image
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font/sfnt"
+
+	"github.com/mbStavola/slydes/pkg/chart"
+	"github.com/mbStavola/slydes/pkg/diagram"
+	"github.com/mbStavola/slydes/pkg/highlight"
+	"github.com/mbStavola/slydes/pkg/tex"
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// The content of a block, which is measured before it is drawn so that
+// the block can be sized and aligned around it
+type content interface {
+	// The size of the content when it may be no wider than maxWidth
+	size(maxWidth float64) (float64, float64)
+	// Draw the content from its top left corner, filling the width
+	draw(c *canvas, x float64, y float64, width float64)
+}
+
+func (c *canvas) content(block types.Block) content {
+	switch {
+	case block.Code != nil:
+		return newCode(c, block)
+	case block.Table != nil:
+		return newTable(c, block)
+	case block.Diagram != nil:
+		return diagramContent{diagram: *block.Diagram, style: c.textStyle(block.Style, false), font: c.fonts.resolve(block.Style, false)}
+	case block.Chart != nil:
+		return newChart(c, block)
+	default:
+		return words{text: plainText(block.Words, block.Style.Transform), style: c.textStyle(block.Style, false), justification: block.Style.Justification}
+	}
+}
+
+// Formulas are written out in Unicode, and the blank lines around the
+// text (which come from the source file) are left out
+func plainText(text string, transform types.TextTransform) string {
+	plain := strings.Builder{}
+	for _, segment := range tex.Split(text) {
+		if !segment.Math {
+			plain.WriteString(segment.Text)
+			continue
+		}
+
+		formula, err := tex.Text(segment.Text)
+		if err != nil {
+			formula = segment.Text
+		}
+
+		if segment.Display {
+			formula = "\n" + formula + "\n"
+		}
+		plain.WriteString(formula)
+	}
+
+	lines := strings.Split(plain.String(), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return transformText(strings.Join(lines, "\n"), transform)
+}
+
+func transformText(text string, transform types.TextTransform) string {
+	switch transform {
+	case types.Uppercase:
+		return strings.ToUpper(text)
+	case types.Lowercase:
+		return strings.ToLower(text)
+	case types.Capitalize:
+		runes := []rune(text)
+		for i := range runes {
+			if i == 0 || unicode.IsSpace(runes[i-1]) {
+				runes[i] = unicode.ToUpper(runes[i])
+			}
+		}
+
+		return string(runes)
+	}
+
+	return text
+}
+
+type words struct {
+	text          string
+	style         textStyle
+	justification types.Justification
+}
+
+func (w words) size(maxWidth float64) (float64, float64) {
+	lines := w.style.wrap(w.text, maxWidth)
+
+	width := 0.0
+	for _, line := range lines {
+		width = math.Max(width, w.style.measure(line))
+	}
+
+	return width, float64(len(lines)) * w.style.lineHeight
+}
+
+func (w words) draw(c *canvas, x float64, y float64, width float64) {
+	left, anchor := justify(w.justification, x, width)
+	for i, line := range w.style.wrap(w.text, width) {
+		c.text(line, point{left, w.style.baseline(y + float64(i)*w.style.lineHeight)}, anchor, false, w.style)
+	}
+}
+
+// Where to anchor lines of text so that they are justified within a width
+func justify(justification types.Justification, x float64, width float64) (float64, anchor) {
+	switch justification {
+	case types.Right:
+		return x + width, end
+	case types.Center:
+		return x + width/2, middle
+	}
+
+	return x, start
+}
+
+// Code is never wrapped, and sits on a colored background with padding
+// around each line as in HTML
+type code struct {
+	lines   []highlight.Line
+	code    types.Code
+	palette highlight.Palette
+	style   textStyle
+	em      float64
+}
+
+func newCode(c *canvas, block types.Block) code {
+	palette := highlight.LightPalette
+	if block.Code.Theme == types.DarkCode {
+		palette = highlight.DarkPalette
+	}
+
+	lines := highlight.Highlight(block.Code.Language, block.Words)
+	for _, line := range lines {
+		for i := range line {
+			line[i].Text = strings.ReplaceAll(line[i].Text, "\t", "    ")
+		}
+	}
+
+	return code{
+		lines:   lines,
+		code:    *block.Code,
+		palette: palette,
+		style:   c.textStyle(block.Style, true),
+		em:      float64(block.Style.Size) * c.scale,
+	}
+}
+
+// The space taken by a line number and the margin after it
+func (k code) gutter() float64 {
+	if !k.code.LineNumbers {
+		return 0
+	}
+
+	digits := k.style.measure(strconv.Itoa(len(k.lines)))
+	return math.Max(2*k.em, digits) + k.em
+}
+
+func (k code) size(maxWidth float64) (float64, float64) {
+	widest := 0.0
+	for _, line := range k.lines {
+		text := ""
+		for _, span := range line {
+			text += span.Text
+		}
+
+		widest = math.Max(widest, k.style.measure(text))
+	}
+
+	return math.Min(maxWidth, widest+k.gutter()+2*k.em), float64(len(k.lines))*k.style.lineHeight + k.em
+}
+
+func (k code) draw(c *canvas, x float64, y float64, width float64) {
+	_, height := k.size(width)
+	c.rect(x, y, width, height, 4*c.scale, k.palette.Background)
+
+	top := y + k.em/2
+	for i, line := range k.lines {
+		number := uint(i + 1)
+		if k.code.IsHighlighted(number) {
+			c.rect(x, top, width, k.style.lineHeight, 0, k.palette.Highlight)
+		}
+
+		baseline := k.style.baseline(top)
+		left := x + k.em
+		if k.code.LineNumbers {
+			label := strconv.Itoa(i + 1)
+			numbers := k.style
+			numbers.color = k.palette.LineNumber
+			c.text(label, point{left + k.gutter() - k.em, baseline}, end, false, numbers)
+			left += k.gutter()
+		}
+
+		for _, span := range line {
+			spanStyle := k.style
+			spanStyle.color = k.palette.Color(span.Kind)
+			c.text(span.Text, point{left, baseline}, start, false, spanStyle)
+			left += spanStyle.measure(span.Text)
+		}
+
+		top += k.style.lineHeight
+	}
+}
+
+// Tables fill their block, with each column given extra space
+// in proportion to how wide its widest cell is
+type table struct {
+	table         types.Table
+	style         textStyle
+	header        textStyle
+	justification types.Justification
+	border        float64
+	// The padding around the text of each cell
+	padX, padY float64
+}
+
+func newTable(c *canvas, block types.Block) table {
+	headerStyle := block.Style
+	headerStyle.Weight = 700
+
+	return table{
+		table:         *block.Table,
+		style:         c.textStyle(block.Style, false),
+		header:        c.textStyle(headerStyle, false),
+		justification: block.Style.Justification,
+		border:        float64(block.Table.Border) * c.scale,
+		padX:          float64(block.Style.Size) * c.scale / 2,
+		padY:          float64(block.Style.Size) * c.scale / 4,
+	}
+}
+
+func (t table) cellStyle(row int) textStyle {
+	if row == 0 && t.table.HeaderRow {
+		return t.header
+	}
+
+	return t.style
+}
+
+func (t table) columnWidths() []float64 {
+	widths := make([]float64, 0)
+	for i, row := range t.table.Rows {
+		for j, cell := range row {
+			for len(widths) <= j {
+				widths = append(widths, 0)
+			}
+
+			widths[j] = math.Max(widths[j], t.cellStyle(i).measure(cell)+2*t.padX)
+		}
+	}
+
+	return widths
+}
+
+func (t table) rowHeight() float64 {
+	return math.Max(t.style.lineHeight, t.header.lineHeight) + 2*t.padY
+}
+
+func (t table) size(maxWidth float64) (float64, float64) {
+	widths := t.columnWidths()
+
+	width := t.border
+	for _, w := range widths {
+		width += w + t.border
+	}
+
+	rows := float64(len(t.table.Rows))
+	return math.Min(maxWidth, width), rows*t.rowHeight() + (rows+1)*t.border
+}
+
+func (t table) draw(c *canvas, x float64, y float64, width float64) {
+	widths := t.columnWidths()
+	if len(widths) == 0 {
+		return
+	}
+
+	natural, _ := t.size(math.Inf(1))
+	extra := width - natural
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	for i := range widths {
+		widths[i] += extra * widths[i] / total
+	}
+
+	rowHeight := t.rowHeight()
+	if t.table.HeaderRow && t.table.HeaderBackground != nil {
+		c.rect(x, y, width, rowHeight+2*t.border, 0, t.table.HeaderBackground)
+	}
+
+	top := y + t.border
+	for i, row := range t.table.Rows {
+		style := t.cellStyle(i)
+
+		left := x + t.border
+		for j, cell := range row {
+			if j >= len(widths) {
+				break
+			}
+
+			at, anchor := justify(t.table.ColumnAlignment(j, t.justification), left+t.padX, widths[j]-2*t.padX)
+			c.text(cell, point{at, style.baseline(top + t.padY)}, anchor, false, style)
+
+			left += widths[j] + t.border
+		}
+
+		top += rowHeight + t.border
+	}
+
+	if t.border == 0 || t.table.BorderColor == nil {
+		return
+	}
+
+	// Borders are drawn last, as lines between and around every cell
+	_, height := t.size(width)
+	left := x
+	for i := 0; i <= len(widths); i++ {
+		c.rect(left, y, t.border, height, 0, t.table.BorderColor)
+		if i < len(widths) {
+			left += widths[i] + t.border
+		}
+	}
+
+	top = y
+	for i := 0; i <= len(t.table.Rows); i++ {
+		c.rect(x, top, width, t.border, 0, t.table.BorderColor)
+		top += rowHeight + t.border
+	}
+}
+
+// Diagrams are centered in their block, and shrunk to fit if too wide
+type diagramContent struct {
+	diagram types.Diagram
+	style   textStyle
+	font    *sfnt.Font
+}
+
+func (d diagramContent) arrange(maxWidth float64) (diagram.Layout, float64) {
+	fontSize := d.style.size
+	layout := diagram.Arrange(d.diagram, fontSize)
+
+	// Every measurement grows with the font size, so a smaller font
+	// gives the same diagram at a smaller size
+	if layout.Width > maxWidth && layout.Width > 0 {
+		fontSize *= maxWidth / layout.Width
+		layout = diagram.Arrange(d.diagram, fontSize)
+	}
+
+	return layout, fontSize
+}
+
+func (d diagramContent) size(maxWidth float64) (float64, float64) {
+	layout, _ := d.arrange(maxWidth)
+	return layout.Width, layout.Height
+}
+
+func (d diagramContent) draw(c *canvas, x float64, y float64, width float64) {
+	layout, fontSize := d.arrange(width)
+	x += (width - layout.Width) / 2
+
+	at := func(p diagram.Point) point {
+		return point{x + p.X, y + p.Y}
+	}
+
+	ink := d.style.color
+	nrgba := color.NRGBAModel.Convert(ink).(color.NRGBA)
+	fill := color.NRGBA{R: nrgba.R, G: nrgba.G, B: nrgba.B, A: nrgba.A / 10}
+	lineWidth := 1.5 * fontSize / d.style.size * c.scale
+
+	for _, box := range layout.Boxes {
+		corner := 4 * fontSize / d.style.size * c.scale
+		c.rect(x+box.X, y+box.Y, box.Width, box.Height, corner, fill)
+		c.strokeRect(x+box.X, y+box.Y, box.Width, box.Height, corner, lineWidth, ink)
+	}
+
+	for _, connector := range layout.Connectors {
+		points := make([]point, len(connector.Points))
+		for i, p := range connector.Points {
+			points[i] = at(p)
+		}
+
+		var dash []float64
+		if connector.Dashed {
+			dash = []float64{6 * lineWidth / 1.5, 4 * lineWidth / 1.5}
+		}
+		c.polyline(points, lineWidth, dash, ink)
+
+		if connector.Arrow && len(points) > 1 {
+			c.polygon(arrowHead(points[len(points)-2], points[len(points)-1], 8*lineWidth), ink)
+		}
+	}
+
+	style := d.style
+	style.face = c.fonts.face(d.font, fontSize)
+	style.size = fontSize
+	style.underline, style.strikethrough, style.letterSpacing = false, false, 0
+
+	for _, text := range layout.Texts {
+		metrics := style.face.Metrics()
+		ascent, descent := float64(metrics.Ascent)/64, float64(metrics.Descent)/64
+		p := at(text.At)
+		c.text(text.Text, point{p.X, p.Y + (ascent-descent)/2}, middle, false, style)
+	}
+}
+
+// Charts fill their block, or take up a reasonable share of the
+// slide when the block is sized to fit its content, as in HTML
+type chartContent struct {
+	chart  types.Chart
+	style  textStyle
+	width  float64
+	height float64
+}
+
+func newChart(c *canvas, block types.Block) chartContent {
+	width := c.width * 0.6
+	if block.Frame.Width != 0 {
+		width = c.width * float64(block.Frame.Width) / 100
+	}
+
+	height := width * 0.6
+	if block.Frame.Height != 0 {
+		height = c.height * float64(block.Frame.Height) / 100
+	}
+
+	return chartContent{chart: *block.Chart, style: c.textStyle(block.Style, false), width: width, height: height}
+}
+
+func (k chartContent) size(maxWidth float64) (float64, float64) {
+	return k.width, k.height
+}
+
+func (k chartContent) draw(c *canvas, x float64, y float64, width float64) {
+	layout := chart.Arrange(k.chart, k.width, k.height, k.style.size, k.style.color)
+	at := func(p chart.Point) point {
+		return point{x + p.X, y + p.Y}
+	}
+
+	style := k.style
+	style.underline, style.strikethrough, style.letterSpacing = false, false, 0
+
+	for _, mark := range layout.Marks {
+		switch mark := mark.(type) {
+		case chart.Rect:
+			c.rect(x+mark.X, y+mark.Y, mark.Width, mark.Height, 0, mark.Fill)
+		case chart.Polyline:
+			points := make([]point, len(mark.Points))
+			for i, p := range mark.Points {
+				points[i] = at(p)
+			}
+
+			c.polyline(points, mark.Width, nil, mark.Stroke)
+		case chart.Circle:
+			c.ellipse(at(mark.Center), mark.Radius, mark.Radius, mark.Fill)
+		case chart.Wedge:
+			c.wedge(at(mark.Center), mark.Radius, mark.Start, mark.Sweep, mark.Fill)
+		case chart.Label:
+			c.drawLabel(mark, at(mark.At), style)
+		}
+	}
+}
+
+func (c *canvas) drawLabel(label chart.Label, at point, style textStyle) {
+	metrics := style.face.Metrics()
+	ascent, descent := float64(metrics.Ascent)/64, float64(metrics.Descent)/64
+
+	// How far the baseline sits below the label's position, which for
+	// vertical text is to its right
+	var drop float64
+	switch label.Baseline {
+	case chart.Top:
+		drop = ascent
+	case chart.Central:
+		drop = (ascent - descent) / 2
+	case chart.Bottom:
+		drop = -descent
+	}
+
+	anchor := []anchor{start, middle, end}[label.Anchor]
+	if label.Vertical {
+		c.text(label.Text, point{at.X + drop, at.Y}, anchor, true, style)
+		return
+	}
+
+	c.text(label.Text, point{at.X, at.Y + drop}, anchor, false, style)
+}
@@ -0,0 +1,162 @@
+package image
+
+import (
+	"math"
+)
+
+// A point in device pixels
+type point struct {
+	X float64
+	Y float64
+}
+
+// A rectangle wound clockwise, like every other outline here
+func rectangle(x float64, y float64, width float64, height float64) []point {
+	return []point{{x, y}, {x + width, y}, {x + width, y + height}, {x, y + height}}
+}
+
+// A rectangle with its corners rounded off
+func roundedRectangle(x float64, y float64, width float64, height float64, radius float64) []point {
+	radius = math.Min(radius, math.Min(width, height)/2)
+	if radius <= 0 {
+		return rectangle(x, y, width, height)
+	}
+
+	points := make([]point, 0, 40)
+	points = append(points, arc(point{x + width - radius, y + radius}, radius, radius, -math.Pi/2, math.Pi/2)...)
+	points = append(points, arc(point{x + width - radius, y + height - radius}, radius, radius, 0, math.Pi/2)...)
+	points = append(points, arc(point{x + radius, y + height - radius}, radius, radius, math.Pi/2, math.Pi/2)...)
+	points = append(points, arc(point{x + radius, y + radius}, radius, radius, math.Pi, math.Pi/2)...)
+
+	return points
+}
+
+// An ellipse as a polygon with enough sides to look smooth at its size
+func ellipse(center point, rx float64, ry float64) []point {
+	return arc(center, rx, ry, 0, 2*math.Pi)
+}
+
+// Points along an elliptical arc, sweeping clockwise from start
+func arc(center point, rx float64, ry float64, start float64, sweep float64) []point {
+	steps := int(math.Max(8, math.Ceil(math.Max(rx, ry)*math.Abs(sweep)/4)))
+
+	points := make([]point, steps+1)
+	for i := range points {
+		angle := start + sweep*float64(i)/float64(steps)
+		points[i] = point{center.X + rx*math.Cos(angle), center.Y + ry*math.Sin(angle)}
+	}
+
+	return points
+}
+
+func reversed(points []point) []point {
+	flipped := make([]point, len(points))
+	for i, p := range points {
+		flipped[len(points)-1-i] = p
+	}
+
+	return flipped
+}
+
+// Wind a polygon clockwise, whichever way it was given
+func clockwise(points []point) []point {
+	area := 0.0
+	for i, p := range points {
+		next := points[(i+1)%len(points)]
+		area += p.X*next.Y - next.X*p.Y
+	}
+
+	if area < 0 {
+		return reversed(points)
+	}
+
+	return points
+}
+
+// The outline of a line through the points, as wide as width, with round
+// joins between its segments and flat ends
+func strokeContours(points []point, width float64) [][]point {
+	contours := make([][]point, 0, 2*len(points))
+	half := width / 2
+
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		length := math.Hypot(to.X-from.X, to.Y-from.Y)
+		if length == 0 {
+			continue
+		}
+
+		// Offset each end of the segment to either side of it
+		nx, ny := -(to.Y-from.Y)/length*half, (to.X-from.X)/length*half
+		contours = append(contours, clockwise([]point{
+			{from.X + nx, from.Y + ny},
+			{to.X + nx, to.Y + ny},
+			{to.X - nx, to.Y - ny},
+			{from.X - nx, from.Y - ny},
+		}))
+
+		if i < len(points)-1 {
+			contours = append(contours, ellipse(to, half, half))
+		}
+	}
+
+	return contours
+}
+
+// Split a line into dashes, alternating between drawn and skipped lengths
+func dashes(points []point, on float64, off float64) [][]point {
+	pieces := make([][]point, 0)
+	current := []point{points[0]}
+
+	// How much of the current dash (or gap between dashes) is left to go
+	drawing, left := true, on
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		length := math.Hypot(to.X-from.X, to.Y-from.Y)
+
+		travelled := 0.0
+		for length-travelled > left {
+			travelled += left
+			at := point{from.X + (to.X-from.X)*travelled/length, from.Y + (to.Y-from.Y)*travelled/length}
+
+			if drawing {
+				pieces = append(pieces, append(current, at))
+				left = off
+			} else {
+				current = []point{at}
+				left = on
+			}
+			drawing = !drawing
+		}
+
+		left -= length - travelled
+		if drawing {
+			current = append(current, to)
+		}
+	}
+
+	if drawing && len(current) > 1 {
+		pieces = append(pieces, current)
+	}
+
+	return pieces
+}
+
+// A triangular arrow head whose tip is at the end of the line, pointing
+// along the line's last segment
+func arrowHead(from point, tip point, length float64) []point {
+	distance := math.Hypot(tip.X-from.X, tip.Y-from.Y)
+	if distance == 0 {
+		return nil
+	}
+
+	dx, dy := (tip.X-from.X)/distance, (tip.Y-from.Y)/distance
+	base := point{tip.X - dx*length, tip.Y - dy*length}
+	half := length / 2
+
+	return clockwise([]point{
+		tip,
+		{base.X - dy*half, base.Y + dx*half},
+		{base.X + dy*half, base.Y - dx*half},
+	})
+}
@@ -0,0 +1,314 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"math"
+	"os"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+	_ "golang.org/x/image/webp"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// A raster paints onto the pixels of an image
+type raster struct {
+	dst        *image.RGBA
+	scale      float64
+	rasterizer *vector.Rasterizer
+}
+
+func newRaster(width int, height int, scale float64) *raster {
+	return &raster{
+		dst:        image.NewRGBA(image.Rect(0, 0, width, height)),
+		scale:      scale,
+		rasterizer: vector.NewRasterizer(width, height),
+	}
+}
+
+// The most pixels an image may have, enough for the largest show at
+// full size, so that no scale or size can exhaust memory
+const maxPixels = types.MaxDimension * types.MaxDimension
+
+func rasterize(dimensions types.Dimensions, slide types.Slide, scale float64, fonts *fonts) (*image.RGBA, error) {
+	// Sized as floats first, since the product could overflow an int
+	width, height := math.Round(float64(dimensions.Width)*scale), math.Round(float64(dimensions.Height)*scale)
+	if !(width*height <= maxPixels) {
+		return nil, fmt.Errorf("a scale of %g makes %gx%g pixel images, more than the %d pixels an image may have", scale, width, height, maxPixels)
+	} else if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("a scale of %g leaves nothing to draw", scale)
+	}
+
+	r := newRaster(int(width), int(height), scale)
+	if err := drawSlide(r, dimensions, slide, scale, fonts); err != nil {
+		return nil, err
+	}
+
+	return r.dst, nil
+}
+
+// Fill the shape outlined by the contours. Contours wound the opposite
+// way to the first cut holes out of it, while those wound the same way
+// add to it, so overlapping pieces of one stroke don't leave gaps
+func (r *raster) fill(contours [][]point, paint color.Color) {
+	if paint == nil || len(contours) == 0 {
+		return
+	}
+
+	bounds := r.dst.Bounds()
+	r.rasterizer.Reset(bounds.Dx(), bounds.Dy())
+	for _, contour := range contours {
+		if len(contour) < 3 {
+			continue
+		}
+
+		r.rasterizer.MoveTo(float32(contour[0].X), float32(contour[0].Y))
+		for _, p := range contour[1:] {
+			r.rasterizer.LineTo(float32(p.X), float32(p.Y))
+		}
+		r.rasterizer.ClosePath()
+	}
+
+	r.rasterizer.Draw(r.dst, bounds, image.NewUniform(paint), image.Point{})
+}
+
+func (r *raster) rect(x float64, y float64, width float64, height float64, radius float64, paint color.Color) {
+	r.fill([][]point{roundedRectangle(x, y, width, height, radius)}, paint)
+}
+
+// Strokes are drawn as the band between a larger and a smaller outline
+func (r *raster) strokeRect(x float64, y float64, width float64, height float64, radius float64, lineWidth float64, paint color.Color) {
+	half := lineWidth / 2
+
+	r.fill([][]point{
+		roundedRectangle(x-half, y-half, width+lineWidth, height+lineWidth, radius+half),
+		reversed(roundedRectangle(x+half, y+half, math.Max(0, width-lineWidth), math.Max(0, height-lineWidth), math.Max(0, radius-half))),
+	}, paint)
+}
+
+func (r *raster) ellipse(center point, rx float64, ry float64, paint color.Color) {
+	r.fill([][]point{ellipse(center, rx, ry)}, paint)
+}
+
+func (r *raster) strokeEllipse(center point, rx float64, ry float64, lineWidth float64, paint color.Color) {
+	half := lineWidth / 2
+
+	r.fill([][]point{
+		ellipse(center, rx+half, ry+half),
+		reversed(ellipse(center, math.Max(0, rx-half), math.Max(0, ry-half))),
+	}, paint)
+}
+
+func (r *raster) polygon(points []point, paint color.Color) {
+	r.fill([][]point{clockwise(points)}, paint)
+}
+
+func (r *raster) polyline(points []point, lineWidth float64, dash []float64, paint color.Color) {
+	pieces := [][]point{points}
+	if len(dash) == 2 {
+		pieces = dashes(points, dash[0], dash[1])
+	}
+
+	for _, piece := range pieces {
+		r.fill(strokeContours(piece, lineWidth), paint)
+	}
+}
+
+func (r *raster) wedge(center point, radius float64, start float64, sweep float64, paint color.Color) {
+	outline := arc(center, radius, radius, start, sweep)
+	if sweep < 2*math.Pi-1e-9 {
+		outline = append([]point{center}, outline...)
+	}
+
+	r.fill([][]point{outline}, paint)
+}
+
+func (r *raster) text(text string, at point, anchor anchor, vertical bool, style textStyle) {
+	width := style.measure(text)
+
+	var shift float64
+	switch anchor {
+	case middle:
+		shift = width / 2
+	case end:
+		shift = width
+	}
+
+	if !vertical {
+		r.drawText(text, at.X-shift, at.Y, style)
+		return
+	}
+
+	// Vertical text is drawn level onto a scratch image, which is then
+	// turned a quarter turn counterclockwise onto the slide
+	metrics := style.face.Metrics()
+	ascent, descent := float64(metrics.Ascent)/64, float64(metrics.Descent)/64
+	level := newRaster(int(math.Ceil(width)), int(math.Ceil(ascent+descent)), r.scale)
+	level.drawText(text, 0, ascent, style)
+
+	bounds := level.dst.Bounds()
+	turned := image.NewRGBA(image.Rect(0, 0, bounds.Dy(), bounds.Dx()))
+	for ty := 0; ty < bounds.Dy(); ty++ {
+		for tx := 0; tx < bounds.Dx(); tx++ {
+			turned.Set(ty, bounds.Dx()-1-tx, level.dst.At(tx, ty))
+		}
+	}
+
+	// Along the turned text, the anchor is measured upwards from the bottom
+	left := at.X - ascent
+	top := at.Y - (width - shift)
+	target := image.Rect(int(math.Round(left)), int(math.Round(top)), int(math.Round(left))+bounds.Dy(), int(math.Round(top))+bounds.Dx())
+	draw.Draw(r.dst, target, turned, image.Point{}, draw.Over)
+}
+
+// Draw a line of text from x with its baseline at y
+func (r *raster) drawText(text string, x float64, y float64, style textStyle) {
+	drawer := font.Drawer{
+		Dst:  r.dst,
+		Src:  image.NewUniform(style.color),
+		Face: style.face,
+		Dot:  fixed.Point26_6{X: toFixed(x), Y: toFixed(y)},
+	}
+
+	if style.letterSpacing == 0 {
+		drawer.DrawString(text)
+	} else {
+		for _, char := range text {
+			drawer.DrawString(string(char))
+			drawer.Dot.X += toFixed(style.letterSpacing)
+		}
+	}
+
+	width := float64(drawer.Dot.X)/64 - x
+
+	// Decorations are as thick as the font's strokes, roughly
+	thickness := math.Max(1, style.size/16)
+	if style.underline {
+		r.rect(x, y+style.size*0.12, width, thickness, 0, style.color)
+	}
+	if style.strikethrough {
+		ascent := float64(style.face.Metrics().Ascent) / 64
+		r.rect(x, y-ascent*0.35-thickness/2, width, thickness, 0, style.color)
+	}
+}
+
+// Gradients follow CSS: a linear gradient's line runs through the middle
+// of the slide at its angle, long enough that its ends reach the corners,
+// and a radial gradient is a circle reaching out to the furthest corner
+func (r *raster) gradient(gradient types.Gradient) {
+	bounds := r.dst.Bounds()
+	width, height := float64(bounds.Dx()), float64(bounds.Dy())
+	dx, dy, length, radius := gradientGeometry(gradient, width, height)
+
+	stops := make([]color.NRGBA, len(gradient.Stops))
+	for i, stop := range gradient.Stops {
+		stops[i] = color.NRGBAModel.Convert(stop).(color.NRGBA)
+	}
+
+	row := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), 1))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			px, py := float64(x)+0.5-width/2, float64(y)+0.5-height/2
+
+			t := math.Hypot(px, py) / radius
+			if gradient.Kind == types.LinearGradient {
+				t = (px*dx+py*dy)/length + 0.5
+			}
+
+			row.Set(x, 0, blend(stops, t))
+		}
+
+		draw.Draw(r.dst, image.Rect(0, y, bounds.Dx(), y+1), row, image.Point{}, draw.Over)
+	}
+}
+
+// The direction and length of a linear gradient's line, and the radius
+// of a radial gradient, on a slide of the given size
+func gradientGeometry(gradient types.Gradient, width float64, height float64) (float64, float64, float64, float64) {
+	angle := float64(gradient.Angle) * math.Pi / 180
+	dx, dy := math.Sin(angle), -math.Cos(angle)
+
+	return dx, dy, math.Abs(width*dx) + math.Abs(height*dy), math.Hypot(width/2, height/2)
+}
+
+// The color a fraction of the way along evenly spaced stops
+func blend(stops []color.NRGBA, t float64) color.Color {
+	if len(stops) == 1 {
+		return stops[0]
+	}
+
+	position := math.Max(0, math.Min(1, t)) * float64(len(stops)-1)
+	i := int(math.Min(math.Floor(position), float64(len(stops)-2)))
+	f := position - float64(i)
+
+	from, to := stops[i], stops[i+1]
+	mix := func(a uint8, b uint8) uint8 {
+		return uint8(math.Round(float64(a)*(1-f) + float64(b)*f))
+	}
+
+	return color.NRGBA{R: mix(from.R, to.R), G: mix(from.G, to.G), B: mix(from.B, to.B), A: mix(from.A, to.A)}
+}
+
+func (r *raster) picture(background types.Image) error {
+	file, err := os.Open(background.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	picture, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("can't draw the background image %q: %w", background.Path, err)
+	}
+
+	bounds := r.dst.Bounds()
+	natural := picture.Bounds()
+	tiles := backgroundTiles(background.Fit, float64(bounds.Dx()), float64(bounds.Dy()), float64(natural.Dx()), float64(natural.Dy()), r.scale)
+
+	for _, at := range tiles {
+		target := image.Rect(int(math.Round(at.X)), int(math.Round(at.Y)), int(math.Round(at.X+at.width)), int(math.Round(at.Y+at.height)))
+		draw.CatmullRom.Scale(r.dst, target, picture, natural, draw.Over, nil)
+	}
+
+	return nil
+}
+
+// Where a background image is drawn, once or as many times as it takes to
+// tile the slide. Images are centered, and drawn at their natural size
+// when tiled
+type tile struct {
+	point
+	width  float64
+	height float64
+}
+
+func backgroundTiles(fit types.ImageFit, width float64, height float64, pictureWidth float64, pictureHeight float64, scale float64) []tile {
+	switch fit {
+	case types.Fit:
+		scale = math.Min(width/pictureWidth, height/pictureHeight)
+	case types.Cover:
+		scale = math.Max(width/pictureWidth, height/pictureHeight)
+	}
+
+	tileWidth, tileHeight := pictureWidth*scale, pictureHeight*scale
+	x, y := (width-tileWidth)/2, (height-tileHeight)/2
+	if fit != types.Tile {
+		return []tile{{point{x, y}, tileWidth, tileHeight}}
+	}
+
+	tiles := make([]tile, 0)
+	for top := math.Mod(y, tileHeight) - tileHeight; top < height; top += tileHeight {
+		for left := math.Mod(x, tileWidth) - tileWidth; left < width; left += tileWidth {
+			tiles = append(tiles, tile{point{left, top}, tileWidth, tileHeight})
+		}
+	}
+
+	return tiles
+}
@@ -0,0 +1,328 @@
+// Package image draws the slides of a show as images, either rasterized to
+// PNG for thumbnails and for comparing how slides look from one change to
+// the next, or as SVG for documentation which needs to scale crisply
+//
+// Slides are laid out as the HTML renderer lays them out. Text is measured
+// in the Go fonts unless the show ships a TrueType or OpenType font for it,
+// which are also the fonts PNG images are drawn in
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// Render writes each slide to its own PNG file in the directory (ex:
+// slide-01.png), at its authored size multiplied by the scale
+func Render(show types.Show, dir string, scale float64) error {
+	fonts, err := newFonts(show.Fonts)
+	if err != nil {
+		return err
+	}
+
+	for i, slide := range show.Slides {
+		img, err := rasterize(show.Dimensions, slide, scale, fonts)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Create(filepath.Join(dir, slideFilename(show, i, "png")))
+		if err != nil {
+			return err
+		}
+
+		if err := png.Encode(file, img); err != nil {
+			file.Close()
+			return err
+		}
+
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenderSVG writes each slide to its own SVG file in the directory (ex:
+// slide-01.svg), with any fonts shipped with the show embedded in each
+func RenderSVG(show types.Show, dir string) error {
+	fonts, err := newFonts(show.Fonts)
+	if err != nil {
+		return err
+	}
+
+	faces, err := fontFaces(show.Fonts)
+	if err != nil {
+		return err
+	}
+
+	for i, slide := range show.Slides {
+		document, err := vectorize(show.Dimensions, slide, fonts, faces)
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, slideFilename(show, i, "svg"))
+		if err := ioutil.WriteFile(path, []byte(document), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Slides are numbered from one, padded so that they sort in order
+func slideFilename(show types.Show, index int, extension string) string {
+	digits := len(strconv.Itoa(len(show.Slides)))
+	return fmt.Sprintf("slide-%0*d.%s", digits, index+1, extension)
+}
+
+// Slide rasterizes a single slide of the show, at its authored size
+// multiplied by the scale
+func Slide(show types.Show, index int, scale float64) (*image.RGBA, error) {
+	if index < 0 || index >= len(show.Slides) {
+		return nil, fmt.Errorf("there is no slide %d", index+1)
+	}
+
+	fonts, err := newFonts(show.Fonts)
+	if err != nil {
+		return nil, err
+	}
+
+	return rasterize(show.Dimensions, show.Slides[index], scale, fonts)
+}
+
+// SlideSVG draws a single slide of the show as an SVG document, at its
+// authored size
+func SlideSVG(show types.Show, index int) (string, error) {
+	if index < 0 || index >= len(show.Slides) {
+		return "", fmt.Errorf("there is no slide %d", index+1)
+	}
+
+	fonts, err := newFonts(show.Fonts)
+	if err != nil {
+		return "", err
+	}
+
+	faces, err := fontFaces(show.Fonts)
+	if err != nil {
+		return "", err
+	}
+
+	return vectorize(show.Dimensions, show.Slides[index], fonts, faces)
+}
+
+// A canvas is the surface a slide is being drawn onto. Everything drawn
+// on it is measured in device pixels, which are authored pixels times scale
+type canvas struct {
+	surface
+	width  float64
+	height float64
+	scale  float64
+	fonts  *fonts
+}
+
+// A surface is either an image, whose pixels are painted, or an SVG
+// document, which is written out as elements
+type surface interface {
+	// A rectangle with its corners rounded off to the radius
+	rect(x float64, y float64, width float64, height float64, radius float64, paint color.Color)
+	// A line along the edge of a rounded rectangle, centered on its edge
+	strokeRect(x float64, y float64, width float64, height float64, radius float64, lineWidth float64, paint color.Color)
+	ellipse(center point, rx float64, ry float64, paint color.Color)
+	strokeEllipse(center point, rx float64, ry float64, lineWidth float64, paint color.Color)
+	polygon(points []point, paint color.Color)
+	// A line through the points with round joins and flat ends. The dash
+	// alternates between lengths drawn and skipped, or is empty for none
+	polyline(points []point, lineWidth float64, dash []float64, paint color.Color)
+	// A slice of a circle, sweeping clockwise from start, in radians
+	wedge(center point, radius float64, start float64, sweep float64, paint color.Color)
+	// A line of text whose baseline runs through the point. Vertical text
+	// is turned a quarter turn counterclockwise about the point
+	text(text string, at point, anchor anchor, vertical bool, style textStyle)
+	// Gradients and images cover the whole slide
+	gradient(gradient types.Gradient)
+	picture(background types.Image) error
+}
+
+// Which part of a line of text sits at its position
+type anchor int
+
+const (
+	start anchor = iota
+	middle
+	end
+)
+
+func drawSlide(surface surface, dimensions types.Dimensions, slide types.Slide, scale float64, fonts *fonts) error {
+	c := &canvas{
+		surface: surface,
+		width:   float64(dimensions.Width) * scale,
+		height:  float64(dimensions.Height) * scale,
+		scale:   scale,
+		fonts:   fonts,
+	}
+
+	if err := c.drawBackground(slide); err != nil {
+		return err
+	}
+
+	c.drawShapes(slide.Shapes)
+
+	// Blocks without a frame flow down the content area, which sits in the
+	// middle 90% of the slide with 32 pixels of padding, as in HTML
+	padding := 32 * scale
+	contentWidth := c.width * 0.9
+	left := (c.width-contentWidth-2*padding)/2 + padding
+	flow := padding
+
+	for _, block := range slide.Blocks {
+		if !block.Positioned {
+			flow += c.drawBlock(block, left, flow, contentWidth, 0)
+			continue
+		}
+
+		c.drawBlock(
+			block,
+			float64(block.Frame.X)/100*c.width,
+			float64(block.Frame.Y)/100*c.height,
+			float64(block.Frame.Width)/100*c.width,
+			float64(block.Frame.Height)/100*c.height,
+		)
+	}
+
+	c.drawDecorations(slide)
+
+	return nil
+}
+
+// Draw a block at the position, returning its height. A zero width sizes
+// the block to fit its content, as does a zero height
+func (c *canvas) drawBlock(block types.Block, x float64, y float64, width float64, height float64) float64 {
+	padding := float64(block.Style.Padding) * c.scale
+	content := c.content(block)
+
+	if width == 0 {
+		// Blocks may grow up to the right edge of the slide
+		available := c.width - x - 2*padding
+		contentWidth, _ := content.size(math.Max(0, available))
+		width = contentWidth + 2*padding
+	}
+
+	innerWidth := math.Max(0, width-2*padding)
+	_, contentHeight := content.size(innerWidth)
+	if height == 0 {
+		height = contentHeight + 2*padding
+	}
+
+	top := y + padding
+	switch block.Style.VerticalAlignment {
+	case types.Middle:
+		top += (height - 2*padding - contentHeight) / 2
+	case types.Bottom:
+		top += height - 2*padding - contentHeight
+	}
+
+	content.draw(c, x+padding, top, innerWidth)
+
+	return height
+}
+
+// Paint the slide's color, then its gradient, then its image on top
+func (c *canvas) drawBackground(slide types.Slide) error {
+	if slide.Background != nil {
+		c.rect(0, 0, c.width, c.height, 0, slide.Background)
+	}
+
+	if gradient := slide.BackgroundGradient; gradient != nil && len(gradient.Stops) > 0 {
+		c.gradient(*gradient)
+	}
+
+	if background := slide.BackgroundImage; background != nil {
+		return c.picture(*background)
+	}
+
+	return nil
+}
+
+// Decorations are drawn in small grey text, as in HTML
+func (c *canvas) drawDecorations(slide types.Slide) {
+	style := types.NewStyle()
+	style.Font = "sans-serif"
+	style.Size = 16
+	style.Color = color.NRGBA{R: 128, G: 128, B: 128, A: 230}
+
+	text := c.textStyle(style, false)
+	edge, side := 16*c.scale, 24*c.scale
+	bottom := text.baseline(c.height - edge - text.lineHeight)
+
+	if slide.Header != "" {
+		c.text(slide.Header, point{c.width / 2, text.baseline(edge)}, middle, false, text)
+	}
+	if slide.Footer != "" {
+		c.text(slide.Footer, point{side, bottom}, start, false, text)
+	}
+	if slide.Number != "" {
+		c.text(slide.Number, point{c.width - side, bottom}, end, false, text)
+	}
+}
+
+func (c *canvas) drawShapes(shapes []types.Shape) {
+	at := func(p types.Point) point {
+		return point{float64(p.X) / 100 * c.width, float64(p.Y) / 100 * c.height}
+	}
+
+	for _, shape := range shapes {
+		strokeWidth := float64(shape.StrokeWidth) * c.scale
+		frame := shape.Frame
+		x, y := float64(frame.X)/100*c.width, float64(frame.Y)/100*c.height
+		w, h := float64(frame.Width)/100*c.width, float64(frame.Height)/100*c.height
+
+		switch shape.Kind {
+		case types.Rectangle:
+			if shape.Fill != nil {
+				c.rect(x, y, w, h, 0, shape.Fill)
+			}
+			if shape.Stroke != nil && strokeWidth > 0 {
+				c.strokeRect(x, y, w, h, 0, strokeWidth, shape.Stroke)
+			}
+		case types.Ellipse:
+			center := point{x + w/2, y + h/2}
+			if shape.Fill != nil {
+				c.ellipse(center, w/2, h/2, shape.Fill)
+			}
+			if shape.Stroke != nil && strokeWidth > 0 {
+				c.strokeEllipse(center, w/2, h/2, strokeWidth, shape.Stroke)
+			}
+		case types.Line, types.Arrow:
+			if shape.Stroke == nil || strokeWidth == 0 {
+				continue
+			}
+
+			from, to := at(shape.From), at(shape.To)
+			if shape.Kind == types.Arrow {
+				// The head is six times the width of the line, and the line
+				// stops short of its tip so that the tip stays sharp
+				head := strokeWidth * 6
+				length := math.Hypot(to.X-from.X, to.Y-from.Y)
+				c.polygon(arrowHead(from, to, head), shape.Stroke)
+
+				if length > head {
+					to = point{to.X - (to.X-from.X)/length*head/2, to.Y - (to.Y-from.Y)/length*head/2}
+				}
+			}
+
+			c.polyline([]point{from, to}, strokeWidth, nil, shape.Stroke)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package image
+
+import (
+	"encoding/xml"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mbStavola/slydes/pkg/lang"
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// The horizontal extent of every pixel which differs from the background
+func inkBounds(img *image.RGBA, background color.Color) (int, int) {
+	r, g, b, _ := background.RGBA()
+
+	left, right := img.Bounds().Dx(), -1
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			pr, pg, pb, _ := img.At(x, y).RGBA()
+			if pr != r || pg != g || pb != b {
+				if x < left {
+					left = x
+				}
+				if x > right {
+					right = x
+				}
+			}
+		}
+	}
+
+	return left, right
+}
+
+func TestSlide(t *testing.T) {
+	source := `
+	slide first {
+		self.backgroundColor = "navy";
+
+		block a {
+			self.fontColor = "white";
+			self.fontSize = 40;
+			self.justify = "right";
+			---Right---
+		}
+	}
+
+	slide second {
+		block a {
+			self.justify = "center";
+			---Centered---
+		}
+	}`
+
+	show, err := lang.NewSly().ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	img, err := Slide(show, 0, 0.5)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if size := img.Bounds().Size(); size.X != 640 || size.Y != 360 {
+		t.Errorf("Expected a 640x360 image-- got %v", size)
+		return
+	}
+
+	navy := color.RGBA{B: 128, A: 255}
+	if r, g, b, _ := img.At(2, 2).RGBA(); r != 0 || g != 0 || b>>8 != 128 {
+		t.Errorf("Expected the background to be navy-- got %v", img.At(2, 2))
+		return
+	}
+
+	// The content area ends 5% in from the right edge of the slide
+	left, right := inkBounds(img, navy)
+	if left < 320 || right < 590 || right > 610 {
+		t.Errorf("Expected the text against the right of the content area-- got %d to %d", left, right)
+		return
+	}
+
+	img, err = Slide(show, 1, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	left, right = inkBounds(img, color.White)
+	if middle := (left + right) / 2; middle < 630 || middle > 650 {
+		t.Errorf("Expected the text in the middle of the slide-- got %d to %d", left, right)
+		return
+	}
+
+	if _, err := Slide(show, 2, 1); err == nil {
+		t.Errorf("Expected an error for a slide which doesn't exist")
+		return
+	}
+
+	// Too large to allocate, whether from the scale or the show itself
+	if _, err := Slide(show, 0, 1e6); err == nil {
+		t.Errorf("Expected an error for a scale which makes too many pixels")
+		return
+	}
+
+	show.Dimensions = types.Dimensions{Width: 4000000000, Height: 3000000000}
+	if _, err := Slide(show, 0, 1); err == nil {
+		t.Errorf("Expected an error for a show which makes too many pixels")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	c := &canvas{scale: 1}
+	c.fonts, _ = newFonts(nil)
+
+	style := c.textStyle(types.NewStyle(), false)
+	lines := style.wrap("one two three\n\nfour", style.measure("one two"))
+	if len(lines) != 4 || lines[0] != "one two" || lines[1] != "three" || lines[2] != "" || lines[3] != "four" {
+		t.Errorf("Expected words to wrap and line breaks to stay-- got %q", lines)
+	}
+}
+
+func TestSlideSVG(t *testing.T) {
+	source := `
+	slide first {
+		self.backgroundGradient = radialGradient("white", "navy");
+		self.footer = "Fish & Chips";
+
+		block a {
+			self.justify = "center";
+			self.font = fontStack("Fira Sans", "sans-serif");
+			---Centered <text>---
+		}
+
+		ellipse e { self.x = 10; self.y = 10; self.width = 20; self.height = 20; self.fill = "red"; }
+	}`
+
+	show, err := lang.NewSly().ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	svg, err := SlideSVG(show, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Everything drawn must be well formed, however its text is escaped
+	texts := make([]string, 0)
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Errorf("Expected well formed SVG-- got %v", err)
+			return
+		}
+
+		if data, ok := token.(xml.CharData); ok {
+			texts = append(texts, string(data))
+		}
+	}
+
+	if len(texts) != 2 || texts[0] != "Centered <text>" || texts[1] != "Fish & Chips" {
+		t.Errorf("Expected the block and footer as text-- got %q", texts)
+		return
+	}
+
+	expected := []string{
+		`<radialGradient id="background-gradient"`,
+		`<ellipse cx="256" cy="144" rx="128" ry="72" fill="rgba(255, 0, 0, 1)"/>`,
+		`<text x="640" `,
+		`font-family="&quot;Fira Sans&quot;, sans-serif"`,
+		`text-anchor="middle"`,
+	}
+	for _, part := range expected {
+		if !strings.Contains(svg, part) {
+			t.Errorf("Expected the SVG to contain %s-- got %s", part, svg)
+			return
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package image
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"math"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/mbStavola/slydes/pkg/css"
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// A drawing writes out each thing drawn on it as an SVG element, in the
+// order they are drawn so that later elements sit above earlier ones
+type drawing struct {
+	svg    strings.Builder
+	width  float64
+	height float64
+}
+
+// The faces are the CSS rules for the fonts shipped with the show
+func vectorize(dimensions types.Dimensions, slide types.Slide, fonts *fonts, faces string) (string, error) {
+	d := &drawing{width: float64(dimensions.Width), height: float64(dimensions.Height)}
+	fmt.Fprintf(
+		&d.svg,
+		`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 %s %s" width="%s" height="%s" xml:space="preserve">`,
+		css.Number(d.width), css.Number(d.height), css.Number(d.width), css.Number(d.height),
+	)
+
+	if faces != "" {
+		fmt.Fprintf(&d.svg, "<defs><style>%s</style></defs>", escape.Replace(faces))
+	}
+
+	if err := drawSlide(d, dimensions, slide, 1, fonts); err != nil {
+		return "", err
+	}
+
+	d.svg.WriteString("</svg>")
+
+	return d.svg.String(), nil
+}
+
+// Every font shipped with the show is embedded, as the HTML renderer does,
+// so that the text is drawn in it wherever the SVG ends up
+func fontFaces(faces []types.FontFace) (string, error) {
+	rules := strings.Builder{}
+	for _, face := range faces {
+		rule, err := css.FontFace(face)
+		if err != nil {
+			return "", err
+		}
+
+		rules.WriteString(rule)
+	}
+
+	return rules.String(), nil
+}
+
+func (d *drawing) rect(x float64, y float64, width float64, height float64, radius float64, paint color.Color) {
+	fmt.Fprintf(&d.svg, `<rect x="%s" y="%s" width="%s" height="%s"%s fill="%s"/>`, css.Number(x), css.Number(y), css.Number(width), css.Number(height), corners(radius), css.RGBA(paint))
+}
+
+func (d *drawing) strokeRect(x float64, y float64, width float64, height float64, radius float64, lineWidth float64, paint color.Color) {
+	fmt.Fprintf(
+		&d.svg,
+		`<rect x="%s" y="%s" width="%s" height="%s"%s fill="none" stroke="%s" stroke-width="%s"/>`,
+		css.Number(x), css.Number(y), css.Number(width), css.Number(height), corners(radius), css.RGBA(paint), css.Number(lineWidth),
+	)
+}
+
+func corners(radius float64) string {
+	if radius <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(` rx="%s"`, css.Number(radius))
+}
+
+func (d *drawing) ellipse(center point, rx float64, ry float64, paint color.Color) {
+	fmt.Fprintf(&d.svg, `<ellipse cx="%s" cy="%s" rx="%s" ry="%s" fill="%s"/>`, css.Number(center.X), css.Number(center.Y), css.Number(rx), css.Number(ry), css.RGBA(paint))
+}
+
+func (d *drawing) strokeEllipse(center point, rx float64, ry float64, lineWidth float64, paint color.Color) {
+	fmt.Fprintf(
+		&d.svg,
+		`<ellipse cx="%s" cy="%s" rx="%s" ry="%s" fill="none" stroke="%s" stroke-width="%s"/>`,
+		css.Number(center.X), css.Number(center.Y), css.Number(rx), css.Number(ry), css.RGBA(paint), css.Number(lineWidth),
+	)
+}
+
+func (d *drawing) polygon(points []point, paint color.Color) {
+	fmt.Fprintf(&d.svg, `<polygon points="%s" fill="%s"/>`, pointList(points), css.RGBA(paint))
+}
+
+func (d *drawing) polyline(points []point, lineWidth float64, dash []float64, paint color.Color) {
+	fmt.Fprintf(
+		&d.svg,
+		`<polyline points="%s" fill="none" stroke="%s" stroke-width="%s" stroke-linejoin="round"`,
+		pointList(points), css.RGBA(paint), css.Number(lineWidth),
+	)
+	if len(dash) == 2 {
+		fmt.Fprintf(&d.svg, ` stroke-dasharray="%s %s"`, css.Number(dash[0]), css.Number(dash[1]))
+	}
+	d.svg.WriteString("/>")
+}
+
+func pointList(points []point) string {
+	list := make([]string, len(points))
+	for i, p := range points {
+		list[i] = css.Number(p.X) + "," + css.Number(p.Y)
+	}
+
+	return strings.Join(list, " ")
+}
+
+func (d *drawing) wedge(center point, radius float64, start float64, sweep float64, paint color.Color) {
+	// A slice making up the whole pie can't be drawn as an arc
+	if sweep >= 2*math.Pi-1e-9 {
+		d.ellipse(center, radius, radius, paint)
+		return
+	}
+
+	large := 0
+	if sweep > math.Pi {
+		large = 1
+	}
+
+	end := start + sweep
+	fmt.Fprintf(
+		&d.svg,
+		`<path d="M %s %s L %s %s A %s %s 0 %d 1 %s %s Z" fill="%s"/>`,
+		css.Number(center.X), css.Number(center.Y),
+		css.Number(center.X+radius*math.Cos(start)), css.Number(center.Y+radius*math.Sin(start)),
+		css.Number(radius), css.Number(radius), large,
+		css.Number(center.X+radius*math.Cos(end)), css.Number(center.Y+radius*math.Sin(end)),
+		css.RGBA(paint),
+	)
+}
+
+var textAnchors = []string{"start", "middle", "end"}
+
+// Text is positioned by the Go fonts' measurements, but drawn in whichever
+// of its families the viewer has, so lines are anchored where they are
+// justified to rather than where they start
+func (d *drawing) text(text string, at point, anchor anchor, vertical bool, style textStyle) {
+	position := fmt.Sprintf(`x="%s" y="%s"`, css.Number(at.X), css.Number(at.Y))
+	if vertical {
+		position = fmt.Sprintf(`transform="translate(%s %s) rotate(-90)"`, css.Number(at.X), css.Number(at.Y))
+	}
+
+	fmt.Fprintf(
+		&d.svg,
+		`<text %s font-family="%s" font-size="%s" fill="%s"`,
+		position, escape.Replace(css.FontFamilies(style.family)), css.Number(style.size), css.RGBA(style.color),
+	)
+	if anchor != start {
+		fmt.Fprintf(&d.svg, ` text-anchor="%s"`, textAnchors[anchor])
+	}
+	if style.weight != 400 && style.weight != 0 {
+		fmt.Fprintf(&d.svg, ` font-weight="%d"`, style.weight)
+	}
+	if style.italic {
+		d.svg.WriteString(` font-style="italic"`)
+	}
+	if style.letterSpacing != 0 {
+		fmt.Fprintf(&d.svg, ` letter-spacing="%s"`, css.Number(style.letterSpacing))
+	}
+
+	decorations := make([]string, 0, 2)
+	if style.underline {
+		decorations = append(decorations, "underline")
+	}
+	if style.strikethrough {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		fmt.Fprintf(&d.svg, ` text-decoration="%s"`, strings.Join(decorations, " "))
+	}
+
+	fmt.Fprintf(&d.svg, ">%s</text>", escape.Replace(text))
+}
+
+// Gradients follow CSS, as they do in images
+func (d *drawing) gradient(gradient types.Gradient) {
+	dx, dy, length, radius := gradientGeometry(gradient, d.width, d.height)
+	cx, cy := d.width/2, d.height/2
+
+	if gradient.Kind == types.LinearGradient {
+		fmt.Fprintf(
+			&d.svg,
+			`<defs><linearGradient id="background-gradient" gradientUnits="userSpaceOnUse" x1="%s" y1="%s" x2="%s" y2="%s">`,
+			css.Number(cx-dx*length/2), css.Number(cy-dy*length/2), css.Number(cx+dx*length/2), css.Number(cy+dy*length/2),
+		)
+	} else {
+		fmt.Fprintf(
+			&d.svg,
+			`<defs><radialGradient id="background-gradient" gradientUnits="userSpaceOnUse" cx="%s" cy="%s" r="%s">`,
+			css.Number(cx), css.Number(cy), css.Number(radius),
+		)
+	}
+
+	for i, stop := range gradient.Stops {
+		offset := 0.0
+		if len(gradient.Stops) > 1 {
+			offset = float64(i) / float64(len(gradient.Stops)-1)
+		}
+
+		fmt.Fprintf(&d.svg, `<stop offset="%s" stop-color="%s"/>`, css.Number(offset), css.RGBA(stop))
+	}
+
+	if gradient.Kind == types.LinearGradient {
+		d.svg.WriteString("</linearGradient></defs>")
+	} else {
+		d.svg.WriteString("</radialGradient></defs>")
+	}
+
+	fmt.Fprintf(&d.svg, `<rect width="%s" height="%s" fill="url(#background-gradient)"/>`, css.Number(d.width), css.Number(d.height))
+}
+
+// Background images are embedded, so that each file stands on its own
+func (d *drawing) picture(background types.Image) error {
+	data, err := ioutil.ReadFile(background.Path)
+	if err != nil {
+		return err
+	}
+
+	mediaType := mime.TypeByExtension(filepath.Ext(background.Path))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+	}
+	url := fmt.Sprintf("data:%s;base64,%s", mediaType, base64.StdEncoding.EncodeToString(data))
+
+	switch background.Fit {
+	case types.Fit, types.Cover:
+		aspect := "meet"
+		if background.Fit == types.Cover {
+			aspect = "slice"
+		}
+
+		fmt.Fprintf(
+			&d.svg,
+			`<image width="%s" height="%s" preserveAspectRatio="xMidYMid %s" xlink:href="%s"/>`,
+			css.Number(d.width), css.Number(d.height), aspect, url,
+		)
+
+		return nil
+	}
+
+	// Tiles are drawn at the image's natural size, so it must be measured
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("can't draw the background image %q: %w", background.Path, err)
+	}
+
+	pattern := backgroundTiles(background.Fit, d.width, d.height, float64(config.Width), float64(config.Height), 1)[0]
+	fmt.Fprintf(
+		&d.svg,
+		`<defs><pattern id="background-image" patternUnits="userSpaceOnUse" x="%s" y="%s" width="%s" height="%s"><image width="%s" height="%s" preserveAspectRatio="none" xlink:href="%s"/></pattern></defs>`,
+		css.Number(pattern.X), css.Number(pattern.Y), css.Number(pattern.width), css.Number(pattern.height), css.Number(pattern.width), css.Number(pattern.height), url,
+	)
+	fmt.Fprintf(&d.svg, `<rect width="%s" height="%s" fill="url(#background-image)"/>`, css.Number(d.width), css.Number(d.height))
+
+	return nil
+}
+
+// Only what XML requires is escaped. Double quotes become &quot; too,
+// since font names quoted for CSS are written into attributes
+var escape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
@@ -0,0 +1,266 @@
+package image
+
+import (
+	"image/color"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomedium"
+	"golang.org/x/image/font/gofont/gomediumitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// The Go fonts stand in for any family which isn't shipped with the show,
+// indexed by whether they are bold, italic or (for proportional text) medium
+type goFamily struct {
+	regular, medium, bold            *sfnt.Font
+	italic, mediumItalic, boldItalic *sfnt.Font
+}
+
+var (
+	goSans = goFamily{
+		regular:      mustParse(goregular.TTF),
+		medium:       mustParse(gomedium.TTF),
+		bold:         mustParse(gobold.TTF),
+		italic:       mustParse(goitalic.TTF),
+		mediumItalic: mustParse(gomediumitalic.TTF),
+		boldItalic:   mustParse(gobolditalic.TTF),
+	}
+	goMono = goFamily{
+		regular:      mustParse(gomono.TTF),
+		medium:       mustParse(gomono.TTF),
+		bold:         mustParse(gomonobold.TTF),
+		italic:       mustParse(gomonoitalic.TTF),
+		mediumItalic: mustParse(gomonoitalic.TTF),
+		boldItalic:   mustParse(gomonobolditalic.TTF),
+	}
+)
+
+func mustParse(data []byte) *sfnt.Font {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+func (g goFamily) variant(style types.Style) *sfnt.Font {
+	switch {
+	case style.Weight >= 600 && style.Italic:
+		return g.boldItalic
+	case style.Weight >= 600:
+		return g.bold
+	case style.Weight >= 500 && style.Italic:
+		return g.mediumItalic
+	case style.Weight >= 500:
+		return g.medium
+	case style.Italic:
+		return g.italic
+	}
+
+	return g.regular
+}
+
+// Families which are drawn in the Go Mono font when they aren't shipped
+var monospaceHints = []string{"monospace", "mono", "courier", "consolas", "menlo", "code"}
+
+// Fonts finds the font for each style, and keeps the faces made from
+// them so that each font is only prepared once for each size
+type fonts struct {
+	shipped map[string]*sfnt.Font
+	faces   map[faceKey]font.Face
+}
+
+type faceKey struct {
+	font *sfnt.Font
+	size float64
+}
+
+// Only TrueType and OpenType files can be drawn, so any other fonts
+// shipped with the show are left to fall back to the Go fonts
+func newFonts(faces []types.FontFace) (*fonts, error) {
+	f := &fonts{
+		shipped: make(map[string]*sfnt.Font),
+		faces:   make(map[faceKey]font.Face),
+	}
+
+	for _, face := range faces {
+		extension := strings.ToLower(filepath.Ext(face.Path))
+		if extension != ".ttf" && extension != ".otf" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(face.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, err := opentype.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+
+		f.shipped[strings.ToLower(face.Family)] = parsed
+	}
+
+	return f, nil
+}
+
+// Find the first family in the style's font stack which can be drawn,
+// where monospace text falls back to Go Mono rather than Go Sans
+func (f *fonts) resolve(style types.Style, monospace bool) *sfnt.Font {
+	for _, family := range style.FontStack() {
+		family = strings.ToLower(family)
+		if shipped, ok := f.shipped[family]; ok {
+			return shipped
+		}
+
+		for _, hint := range monospaceHints {
+			if strings.Contains(family, hint) {
+				return goMono.variant(style)
+			}
+		}
+	}
+
+	if monospace {
+		return goMono.variant(style)
+	}
+
+	return goSans.variant(style)
+}
+
+// The face of a font at a size in device pixels
+func (f *fonts) face(font *sfnt.Font, size float64) font.Face {
+	key := faceKey{font: font, size: size}
+	if face, ok := f.faces[key]; ok {
+		return face
+	}
+
+	face, err := opentype.NewFace(font, &opentype.FaceOptions{Size: size, DPI: 72})
+	if err != nil {
+		// Only an invalid size can fail, which the caller never asks for
+		panic(err)
+	}
+
+	f.faces[key] = face
+	return face
+}
+
+// A textStyle is everything needed to measure and draw a line of text
+type textStyle struct {
+	face  font.Face
+	color color.Color
+	// The families the text asks for, as written into SVG
+	family []string
+	weight uint16
+	italic bool
+	// In device pixels
+	size          float64
+	letterSpacing float64
+	lineHeight    float64
+	underline     bool
+	strikethrough bool
+}
+
+func (c *canvas) textStyle(style types.Style, monospace bool) textStyle {
+	size := float64(style.Size) * c.scale
+	face := c.fonts.face(c.fonts.resolve(style, monospace), math.Max(1, size))
+
+	lineHeight := float64(face.Metrics().Height) / 64
+	if style.LineHeight != 0 {
+		lineHeight = style.LineHeight * size
+	}
+
+	return textStyle{
+		face:          face,
+		color:         style.Color,
+		family:        style.FontStack(),
+		weight:        style.Weight,
+		italic:        style.Italic,
+		size:          size,
+		letterSpacing: style.LetterSpacing * c.scale,
+		lineHeight:    lineHeight,
+		underline:     style.Underline,
+		strikethrough: style.Strikethrough,
+	}
+}
+
+// The width of a line of text, in device pixels
+func (s textStyle) measure(text string) float64 {
+	width := float64(font.MeasureString(s.face, text)) / 64
+	return width + s.letterSpacing*float64(utf8.RuneCountInString(text))
+}
+
+// Where to put the baseline of a line of text so that it sits in the
+// middle of its line box, as CSS does
+func (s textStyle) baseline(top float64) float64 {
+	metrics := s.face.Metrics()
+	ascent, descent := float64(metrics.Ascent)/64, float64(metrics.Descent)/64
+
+	return top + (s.lineHeight-ascent-descent)/2 + ascent
+}
+
+// Break text into lines which fit within the width, at spaces where
+// possible. Explicit line breaks are kept, and runs of spaces collapse
+func (s textStyle) wrap(text string, width float64) []string {
+	lines := make([]string, 0, 1)
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+
+		line := ""
+		for _, word := range words {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+
+			if s.measure(candidate) <= width {
+				line = candidate
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+
+			// A word too long for a line of its own is broken wherever it must be
+			line = ""
+			for _, char := range word {
+				if line != "" && s.measure(line+string(char)) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(char)
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func toFixed(x float64) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(x * 64))
+}
@@ -0,0 +1,127 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// Lines of an outline are indented by this much for each level
+const outlineIndent = "    "
+
+func outline(show types.Show) string {
+	out := strings.Builder{}
+
+	heading := make([]string, 0, 2)
+	for _, line := range []string{show.Metadata.Title, byline(show.Metadata)} {
+		if line != "" {
+			heading = append(heading, line)
+		}
+	}
+	if len(heading) > 0 {
+		fmt.Fprintf(&out, "%s\n\n", strings.Join(heading, "\n"))
+	}
+
+	for i, slide := range show.Slides {
+		fmt.Fprintf(&out, "Slide %d\n", i+1)
+
+		for _, block := range slide.Blocks {
+			writeIndented(&out, blockOutline(block), outlineIndent)
+		}
+
+		if notes := dedent(slide.Notes); len(notes) > 0 {
+			out.WriteString(outlineIndent + "Notes:\n")
+			writeIndented(&out, notes, outlineIndent+outlineIndent)
+		}
+
+		out.WriteString("\n")
+	}
+
+	return strings.TrimRight(out.String(), "\n") + "\n"
+}
+
+// Blank lines are left empty, rather than holding only the indentation
+func writeIndented(out *strings.Builder, lines []string, indent string) {
+	for _, line := range lines {
+		if line != "" {
+			out.WriteString(indent + line)
+		}
+		out.WriteString("\n")
+	}
+}
+
+func blockOutline(block types.Block) []string {
+	switch {
+	case block.Table != nil:
+		return tableOutline(*block.Table)
+	case block.Diagram != nil:
+		return diagramOutline(*block.Diagram)
+	case block.Chart != nil:
+		return chartOutline(*block.Chart)
+	default:
+		return dedent(block.Words)
+	}
+}
+
+func tableOutline(table types.Table) []string {
+	lines := make([]string, len(table.Rows))
+	for i, row := range table.Rows {
+		lines[i] = strings.Join(row, " | ")
+	}
+
+	return lines
+}
+
+// A diagram is listed as its edges, followed by any nodes left unconnected
+func diagramOutline(diagram types.Diagram) []string {
+	labels := make(map[string]string)
+	for _, node := range diagram.Nodes {
+		labels[node.ID] = node.Label
+	}
+
+	lines := make([]string, 0, len(diagram.Edges))
+	connected := make(map[string]bool)
+	for _, edge := range diagram.Edges {
+		arrow := " -> "
+		if edge.Dashed {
+			arrow = " --> "
+		}
+
+		line := labels[edge.From] + arrow + labels[edge.To]
+		if edge.Label != "" {
+			line += ": " + edge.Label
+		}
+
+		lines = append(lines, line)
+		connected[edge.From], connected[edge.To] = true, true
+	}
+
+	for _, node := range diagram.Nodes {
+		if !connected[node.ID] {
+			lines = append(lines, node.Label)
+		}
+	}
+
+	return lines
+}
+
+// A chart is listed as its kind and series, then the values of each category
+func chartOutline(chart types.Chart) []string {
+	names := make([]string, len(chart.Series))
+	for i, series := range chart.Series {
+		names[i] = series.Name
+	}
+
+	lines := []string{chart.Kind.String() + " chart: " + strings.Join(names, ", ")}
+	for i, category := range chart.Categories {
+		values := make([]string, len(chart.Series))
+		for j, series := range chart.Series {
+			values[j] = chartValue(series, i)
+		}
+
+		lines = append(lines, category+": "+strings.Join(values, ", "))
+	}
+
+	return lines
+}
@@ -0,0 +1,395 @@
+// Package markdown renders the content of a show as a Markdown document,
+// or as a plain text outline
+//
+// Only the content of a show is kept: styles, positions, shapes and
+// backgrounds are left out, as are the headers and footers repeated
+// on every slide
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mbStavola/slydes/pkg/tex"
+	"github.com/mbStavola/slydes/pkg/types"
+)
+
+// Render writes the show as a Markdown document with a section for each
+// slide, in which every block is a paragraph and the notes are quoted
+func Render(show types.Show) error {
+	_, err := fmt.Print(document(show))
+	return err
+}
+
+// RenderOutline writes the text of each slide in turn, indented beneath
+// the slide's number, without any markup
+func RenderOutline(show types.Show) error {
+	_, err := fmt.Print(outline(show))
+	return err
+}
+
+func document(show types.Show) string {
+	doc := strings.Builder{}
+
+	if show.Metadata.Title != "" {
+		fmt.Fprintf(&doc, "# %s\n\n", escapeLine(show.Metadata.Title))
+	}
+	if byline := byline(show.Metadata); byline != "" {
+		fmt.Fprintf(&doc, "*%s*\n\n", escapeLine(byline))
+	}
+	if show.Metadata.Description != "" {
+		fmt.Fprintf(&doc, "%s\n\n", escapeLine(show.Metadata.Description))
+	}
+
+	for i, slide := range show.Slides {
+		fmt.Fprintf(&doc, "## Slide %d\n\n", i+1)
+
+		for _, block := range slide.Blocks {
+			if text := blockMarkdown(block); text != "" {
+				fmt.Fprintf(&doc, "%s\n\n", text)
+			}
+		}
+
+		if notes := wordsMarkdown(slide.Notes); notes != "" {
+			for _, line := range strings.Split(notes, "\n") {
+				doc.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+			}
+			doc.WriteString("\n")
+		}
+	}
+
+	return strings.TrimRight(doc.String(), "\n") + "\n"
+}
+
+// The author and date of a show, as they would appear beneath its title
+func byline(metadata types.Metadata) string {
+	parts := make([]string, 0, 2)
+	for _, part := range []string{metadata.Author, metadata.Date} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func blockMarkdown(block types.Block) string {
+	switch {
+	case block.Code != nil:
+		return fenced(block.Words, block.Code.Language)
+	case block.Table != nil:
+		return tableMarkdown(*block.Table, block.Style.Justification)
+	case block.Diagram != nil:
+		return mermaid(*block.Diagram)
+	case block.Chart != nil:
+		return chartMarkdown(*block.Chart)
+	default:
+		return wordsMarkdown(block.Words)
+	}
+}
+
+// Each run of lines in a block becomes a paragraph, or a list if every
+// line in the run starts with a bullet or a number
+func wordsMarkdown(words string) string {
+	out := make([]string, 0, 1)
+	for _, lines := range paragraphs(dedent(words)) {
+		if isList(lines) {
+			for i, line := range lines {
+				trimmed := strings.TrimLeft(line, " \t")
+				marker := "- "
+				if number := numbered.FindString(trimmed); number != "" {
+					marker = number
+				}
+
+				item := strings.TrimSpace(listItem.ReplaceAllString(trimmed, ""))
+				lines[i] = line[:len(line)-len(trimmed)] + marker + prose(item)
+			}
+
+			out = append(out, strings.Join(lines, "\n"))
+		} else {
+			out = append(out, prose(strings.Join(lines, "\n")))
+		}
+	}
+
+	return strings.Join(out, "\n\n")
+}
+
+func isList(lines []string) bool {
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if !listItem.MatchString(trimmed) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var (
+	listItem = regexp.MustCompile(`^(•|[-*+]\s|\d+[.)]\s)`)
+	numbered = regexp.MustCompile(`^\d+[.)]\s`)
+)
+
+// Escape text so that it reads the same in Markdown, keeping each of its
+// line breaks and leaving any formulas to be rendered as math
+func prose(text string) string {
+	out := strings.Builder{}
+	lineStart := true
+	for _, segment := range tex.Split(text) {
+		if segment.Math {
+			delimiter := "$"
+			if segment.Display {
+				delimiter = "$$"
+			}
+
+			out.WriteString(delimiter + segment.Text + delimiter)
+			lineStart = false
+			continue
+		}
+
+		for i, line := range strings.Split(segment.Text, "\n") {
+			if i > 0 {
+				out.WriteString("\\\n")
+				lineStart = true
+			}
+
+			if lineStart {
+				// Leading space would otherwise indent the line into a code block
+				line = escapeLine(strings.TrimLeft(line, " \t"))
+			} else {
+				line = inline.Replace(line)
+			}
+
+			out.WriteString(line)
+			lineStart = lineStart && line == ""
+		}
+	}
+
+	return out.String()
+}
+
+var inline = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"<", `\<`,
+	"$", `\$`,
+)
+
+// Markdown reads some characters at the start of a line as
+// headings, quotes, lists or rules rather than text
+var blockStart = regexp.MustCompile(`^(#|>|-|\+|=|\||\d+[.)])`)
+
+func escapeLine(line string) string {
+	line = inline.Replace(line)
+	if match := blockStart.FindString(line); match != "" {
+		return match[:len(match)-1] + `\` + line[len(match)-1:]
+	}
+
+	return line
+}
+
+// Fence code with more backticks than it contains in a row
+func fenced(code string, language string) string {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	return fmt.Sprintf("%s%s\n%s\n%s", fence, language, strings.TrimRight(code, "\n"), fence)
+}
+
+func tableMarkdown(table types.Table, justification types.Justification) string {
+	if len(table.Rows) == 0 {
+		return ""
+	}
+
+	// Markdown tables must have a header, so one is left blank if needed
+	rows := table.Rows
+	header := make([]string, len(rows[0]))
+	if table.HeaderRow {
+		header, rows = rows[0], rows[1:]
+	}
+
+	dividers := make([]string, len(header))
+	for i := range dividers {
+		switch table.ColumnAlignment(i, justification) {
+		case types.Left:
+			dividers[i] = "---"
+		case types.Right:
+			dividers[i] = "---:"
+		case types.Center:
+			dividers[i] = ":---:"
+		}
+	}
+
+	lines := make([]string, 0, len(rows)+2)
+	lines = append(lines, tableRow(header), "| "+strings.Join(dividers, " | ")+" |")
+	for _, row := range rows {
+		lines = append(lines, tableRow(row))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func tableRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		cell = strings.Join(strings.Fields(cell), " ")
+		escaped[i] = strings.ReplaceAll(inline.Replace(cell), "|", `\|`)
+	}
+
+	return strings.TrimRight("| "+strings.Join(escaped, " | ")+" |", " ")
+}
+
+// Diagrams are written for Mermaid, which most wikis and code hosts draw
+func mermaid(diagram types.Diagram) string {
+	ids := make(map[string]string)
+	for i, node := range diagram.Nodes {
+		ids[node.ID] = "n" + strconv.Itoa(i)
+	}
+
+	out := strings.Builder{}
+	out.WriteString("```mermaid\n")
+
+	if diagram.Kind == types.Sequence {
+		out.WriteString("sequenceDiagram\n")
+		for _, node := range diagram.Nodes {
+			fmt.Fprintf(&out, "    participant %s as %s\n", ids[node.ID], mermaidText(node.Label))
+		}
+
+		for _, edge := range diagram.Edges {
+			arrow := "->>"
+			if edge.Dashed {
+				arrow = "-->>"
+			}
+
+			fmt.Fprintf(&out, "    %s%s%s: %s\n", ids[edge.From], arrow, ids[edge.To], mermaidText(edge.Label))
+		}
+	} else {
+		direction := "TD"
+		if diagram.Direction == types.LeftToRight {
+			direction = "LR"
+		}
+
+		fmt.Fprintf(&out, "flowchart %s\n", direction)
+		for _, node := range diagram.Nodes {
+			fmt.Fprintf(&out, "    %s[\"%s\"]\n", ids[node.ID], mermaidText(node.Label))
+		}
+
+		for _, edge := range diagram.Edges {
+			arrow := "-->"
+			if edge.Dashed {
+				arrow = "-.->"
+			}
+			if edge.Label != "" {
+				arrow += "|\"" + mermaidText(edge.Label) + "\"|"
+			}
+
+			fmt.Fprintf(&out, "    %s %s %s\n", ids[edge.From], arrow, ids[edge.To])
+		}
+	}
+
+	out.WriteString("```")
+
+	return out.String()
+}
+
+// Mermaid ends statements at semicolons, and writes entities with a #
+var mermaidEscapes = strings.NewReplacer(`"`, "#quot;", ";", "#59;")
+
+func mermaidText(text string) string {
+	return mermaidEscapes.Replace(text)
+}
+
+// A chart becomes a table of its data, with a row for each category
+func chartMarkdown(chart types.Chart) string {
+	header := []string{chart.XLabel}
+	for _, series := range chart.Series {
+		header = append(header, series.Name)
+	}
+
+	// Categories are aligned left and values right, as in a spreadsheet
+	table := types.Table{
+		Rows:             [][]string{header},
+		HeaderRow:        true,
+		ColumnAlignments: []types.Justification{types.Left},
+	}
+	for i, category := range chart.Categories {
+		row := []string{category}
+		for _, series := range chart.Series {
+			row = append(row, chartValue(series, i))
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	caption := chart.Kind.String() + " chart"
+	if chart.YLabel != "" {
+		caption += " of " + chart.YLabel
+	}
+
+	return fmt.Sprintf("*%s*\n\n%s", escapeLine(caption), tableMarkdown(table, types.Right))
+}
+
+func chartValue(series types.ChartSeries, index int) string {
+	if index >= len(series.Values) {
+		return ""
+	}
+
+	return strconv.FormatFloat(series.Values[index], 'f', -1, 64)
+}
+
+// The lines of text without the blank lines around it or the
+// indentation its lines share, which come from the source file
+func dedent(text string) []string {
+	lines := strings.Split(text, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+		if lines[i] == "" {
+			continue
+		}
+
+		if lineIndent := len(lines[i]) - len(strings.TrimLeft(lines[i], " \t")); indent < 0 || lineIndent < indent {
+			indent = lineIndent
+		}
+	}
+
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = line[indent:]
+		}
+	}
+
+	return lines
+}
+
+// Split lines into the runs between blank lines
+func paragraphs(lines []string) [][]string {
+	runs := make([][]string, 0, 1)
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && lines[i] != "" {
+			continue
+		}
+
+		if i > start {
+			runs = append(runs, lines[start:i])
+		}
+		start = i + 1
+	}
+
+	return runs
+}
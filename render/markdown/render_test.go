@@ -0,0 +1,117 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mbStavola/slydes/pkg/lang"
+)
+
+const source = `
+@title = "Launch";
+@author = "Ada";
+
+slide first {
+	self.notes = "Smile";
+
+	block intro {
+		---
+		# Launch plan
+		Costs $5 *per* seat, and $e^{i\pi}$ stays math
+		---
+	}
+
+	block points {
+		---
+		• One
+		  • Nested
+		2. Two
+		---
+	}
+}
+
+slide second {
+	diagram flow {
+		self.direction = "right";
+		---
+		web: Web "app"
+		web -> api: GET
+		api --> db
+		---
+	}
+
+	chart visitors {
+		self.yLabel = "Visitors";
+		---
+		Month, Web, App
+		Jan, 10, 2.5
+		---
+	}
+
+	table prices {
+		---
+		Plan, Price
+		Pro|Max, 10
+		---
+	}
+}`
+
+func TestDocument(t *testing.T) {
+	show, err := lang.NewSly().ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := []string{
+		"# Launch\n\n*Ada*\n\n## Slide 1\n\n",
+		`\# Launch plan\` + "\n" + `Costs \$5 \*per\* seat, and $e^{i\pi}$ stays math`,
+		"- One\n  - Nested\n2. Two",
+		"> Smile",
+		"## Slide 2",
+		"```mermaid\nflowchart LR\n    n0[\"Web #quot;app#quot;\"]\n    n1[\"api\"]\n    n2[\"db\"]\n    n0 -->|\"GET\"| n1\n    n1 -.-> n2\n```",
+		"*Bar chart of Visitors*\n\n|  | Web | App |\n| --- | ---: | ---: |\n| Jan | 10 | 2.5 |",
+		"| Plan | Price |\n| --- | --- |\n| Pro\\|Max | 10 |",
+	}
+
+	doc := document(show)
+	for _, text := range expected {
+		if !strings.Contains(doc, text) {
+			t.Errorf("Expected the document to contain %q-- got\n%s", text, doc)
+			return
+		}
+	}
+}
+
+func TestOutline(t *testing.T) {
+	show, err := lang.NewSly().ReadSlideShowString(source)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := `Launch
+Ada
+
+Slide 1
+    # Launch plan
+    Costs $5 *per* seat, and $e^{i\pi}$ stays math
+    • One
+      • Nested
+    2. Two
+    Notes:
+        Smile
+
+Slide 2
+    Web "app" -> api: GET
+    api --> db
+    Bar chart: Web, App
+    Jan: 10, 2.5
+    Plan | Price
+    Pro|Max | 10
+`
+
+	if text := outline(show); text != expected {
+		t.Errorf("Expected\n%s-- got\n%s", expected, text)
+	}
+}